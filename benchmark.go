@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"mono-ymk/ykm"
+	"os"
+)
+
+func Benchmark() {
+	m := ykm.NewModel(Config.Options)
+
+	if Config.InitModelPath != "" {
+		m = loadModel()
+	}
+
+	if err := m.Prepare(Config.TrainingDataPath); err != nil {
+		log.Fatal(err)
+	}
+
+	b, err := ykm.BenchmarkEStep(m, Config.TrainingDataPath, Config.CrossCheckTolerance, os.Stdout)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("\n%s\n", b)
+
+	if b.Mismatches > 0 {
+		log.Fatalf("Found %d mismatches between expected counts (tolerance: %e)", b.Mismatches, Config.CrossCheckTolerance)
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"mono-ymk/ykm"
+)
+
+func Checksum() {
+	m, err := ykm.LoadModel(Config.InitModelPath, Config.Options)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	printWarnings(m)
+
+	sum, err := ykm.Checksum(m)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%s  %s\n", sum, Config.InitModelPath)
+}
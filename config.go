@@ -1,124 +1,272 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"math"
+	"gopkg.in/yaml.v3"
+	"log"
+	"mono-ymk/ykm"
 	"os"
-	"strconv"
+	"path/filepath"
+	"reflect"
+	"strings"
 )
 
-var Config = struct {
-	ReplaceSparseTokens          bool
-	SparseTokenThreshold         int
-	EnableInteriorInsertions     bool
-	EnableTerminalInsertions     bool
-	EnablePhrasalTranslations    bool
-	EnableFertilityDecomposition bool
-	PhraseLengthLimit            int
-	MaxPhraseLengthDifference    int
-	PhraseFrequencyCutoff        int
-	TrainingDataPath             string
-	TrainingIterationLimit       int
-	TrainingSampleLimit          int
-	TrainingComplexityLimit      int
-	ConcurrentSampleEvaluations  int
-	ParaphraseThreshold          float64
-	InitModelPath                string
-	InitModelIteration           int
-	PrintCorpusLikelihood        bool
-	ExportGraphs                 bool
-	ExportModel                  bool
-	GraphExportDirectory         string
-	ModelExportDirectory         string
-}{}
-
-func init() {
-	defer fmt.Printf("%+v\n\n", &Config)
-
-	Config.ReplaceSparseTokens, _, _ = parseEnvBool("REPLACE_SPARSE_TOKENS", false)
-	Config.SparseTokenThreshold, _, _ = parseEnvInt("SPARSE_TOKEN_THRESHOLD", 1)
-
-	Config.EnableInteriorInsertions, _, _ = parseEnvBool("ENABLE_INTERIOR_INSERTIONS", false)
-	Config.EnableTerminalInsertions, _, _ = parseEnvBool("ENABLE_TERMINAL_INSERTIONS", true)
-
-	Config.EnablePhrasalTranslations, _, _ = parseEnvBool("ENABLE_PHRASAL_TRANSLATIONS", true)
-	Config.EnableFertilityDecomposition, _, _ = parseEnvBool("ENABLE_FERTILITY_DECOMPOSITION", true)
-	Config.PhraseLengthLimit, _, _ = parseEnvInt("PHRASE_LENGTH_LIMIT", 0)
-	Config.MaxPhraseLengthDifference, _, _ = parseEnvInt("MAX_PHRASE_LENGTH_DIFFERENCE", 0)
-	Config.PhraseFrequencyCutoff, _, _ = parseEnvInt("PHRASE_FREQUENCY_CUTOFF", 1)
-
-	Config.TrainingDataPath, _ = parseEnvString("TRAINING_DATA_PATH", "")
-	Config.TrainingIterationLimit, _, _ = parseEnvInt("TRAINING_ITERATION_LIMIT", 1)
-	Config.TrainingSampleLimit, _, _ = parseEnvInt("TRAINING_SAMPLE_LIMIT", -1)
-	Config.TrainingComplexityLimit, _, _ = parseEnvInt("TRAINING_COMPLEXITY_LIMIT", -1)
-
-	Config.ConcurrentSampleEvaluations, _, _ = parseEnvInt("CONCURRENT_SAMPLE_EVALUATIONS", 1)
-
-	Config.ParaphraseThreshold, _, _ = parseEnvFloat64("PARAPHRASE_THRESHOLD", math.SmallestNonzeroFloat64)
-
-	Config.InitModelPath, _ = parseEnvString("INIT_MODEL_PATH", "")
-	Config.InitModelIteration, _, _ = parseEnvInt("INIT_MODEL_ITERATION", 1)
-
-	Config.PrintCorpusLikelihood, _, _ = parseEnvBool("PRINT_CORPUS_LIKELIHOOD", false)
-
-	Config.ExportGraphs, _, _ = parseEnvBool("EXPORT_GRAPHS", false)
-	Config.ExportModel, _, _ = parseEnvBool("EXPORT_MODEL", true)
-
-	Config.GraphExportDirectory, _ = parseEnvString("GRAPH_EXPORT_DIRECTORY", "")
-	Config.ModelExportDirectory, _ = parseEnvString("MODEL_EXPORT_DIRECTORY", "")
-
-	ensureDirectoryExists(Config.GraphExportDirectory)
-	ensureDirectoryExists(Config.ModelExportDirectory)
+type Configuration struct {
+	ykm.Options
+
+	CrossCheckTolerance  float64 `env:"CROSS_CHECK_TOLERANCE" default:"1e-9"`
+	ParaphraseThreshold  float64 `env:"PARAPHRASE_THRESHOLD" default:"5e-324"`
+	EvaluationReportPath string  `env:"EVALUATION_REPORT_PATH" default:"evaluation.json"`
+	ScoreNormalization   string  `env:"SCORE_NORMALIZATION" default:"raw"`
+	PredictionOutputPath string  `env:"PREDICTION_OUTPUT_PATH" default:""`
+	ScoreOutputPath      string  `env:"SCORE_OUTPUT_PATH" default:"scores.tsv"`
+	ViterbiOutputPath    string  `env:"VITERBI_OUTPUT_PATH" default:"viterbi.jsonl"`
+	GenerationInputPath  string  `env:"GENERATION_INPUT_PATH" default:""`
+	GenerationOutputPath string  `env:"GENERATION_OUTPUT_PATH" default:"generated.jsonl"`
+	GenerationBeamWidth  int     `env:"GENERATION_BEAM_WIDTH" default:"10"`
+	GenerationTopK       int     `env:"GENERATION_TOP_K" default:"5"`
+	LanguageModelPath    string  `env:"LANGUAGE_MODEL_PATH" default:""`
+	LanguageModelWeight  float64 `env:"LANGUAGE_MODEL_WEIGHT" default:"1"`
+	ServeAddress         string  `env:"SERVE_ADDRESS" default:"localhost:8080"`
+	ShardIndex           int     `env:"SHARD_INDEX" default:"0"`
+	ShardCount           int     `env:"SHARD_COUNT" default:"1"`
+	CountsOutputPath     string  `env:"COUNTS_OUTPUT_PATH" default:"counts.gob"`
+	CountsInputPath      string  `env:"COUNTS_INPUT_PATH" default:""`
 }
 
-func parseEnvString(key, def string) (string, bool) {
-	if val, ok := os.LookupEnv(key); ok {
-		return val, ok
-	}
+var Config = Configuration{}
+
+type configFlag struct {
+	kind  reflect.Kind
+	value string
+}
 
-	return def, false
+func (cf *configFlag) String() string {
+	return cf.value
 }
 
-func parseEnvInt(key string, def int) (int, bool, error) {
-	if val, ok := os.LookupEnv(key); ok {
-		i, err := strconv.Atoi(val)
+func (cf *configFlag) Set(value string) error {
+	cf.value = value
 
-		if err != nil {
-			return def, false, err
+	return nil
+}
+
+func (cf *configFlag) IsBoolFlag() bool {
+	return cf.kind == reflect.Bool
+}
+
+func configKey(env string) string {
+	return strings.ToLower(env)
+}
+
+func configFlagName(env string) string {
+	return strings.ReplaceAll(configKey(env), "_", "-")
+}
+
+func configFields(v reflect.Value) ([]reflect.Value, []reflect.StructField) {
+	values := make([]reflect.Value, 0, v.NumField())
+	fields := make([]reflect.StructField, 0, v.NumField())
+
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).Anonymous {
+			vs, fs := configFields(v.Field(i))
+
+			values = append(values, vs...)
+			fields = append(fields, fs...)
+
+			continue
 		}
 
-		return i, ok, nil
+		values = append(values, v.Field(i))
+		fields = append(fields, v.Type().Field(i))
+	}
+
+	return values, fields
+}
+
+func RegisterConfigFlags(fs *flag.FlagSet) map[string]*configFlag {
+	flags := make(map[string]*configFlag)
+
+	_, fields := configFields(reflect.ValueOf(Configuration{}))
+
+	for _, field := range fields {
+		env := field.Tag.Get("env")
+
+		cf := &configFlag{kind: field.Type.Kind()}
+
+		fs.Var(cf, configFlagName(env), fmt.Sprintf("overrides %s (default %q)", env, field.Tag.Get("default")))
+
+		flags[configFlagName(env)] = cf
 	}
 
-	return def, false, nil
+	return flags
 }
 
-func parseEnvFloat64(key string, def float64) (float64, bool, error) {
-	if val, ok := os.LookupEnv(key); ok {
-		i, err := strconv.ParseFloat(val, 64)
+func LoadConfig(name string, fs *flag.FlagSet, flags map[string]*configFlag) (Configuration, error) {
+	c := Configuration{}
+
+	values, fields := configFields(reflect.ValueOf(&c).Elem())
+
+	index := make(map[string]int, len(fields))
+
+	for i, field := range fields {
+		index[configKey(field.Tag.Get("env"))] = i
+
+		if err := ykm.SetOption(values[i], field.Tag.Get("default")); err != nil {
+			panic(err)
+		}
+	}
+
+	if name != "" {
+		settings, err := readConfigFile(name)
 
 		if err != nil {
-			return def, false, err
+			return c, err
+		}
+
+		for key, val := range settings {
+			i, ok := index[key]
+
+			if !ok {
+				return c, fmt.Errorf("%s: unknown setting: %s", name, key)
+			}
+
+			if err := ykm.SetOption(values[i], fmt.Sprint(val)); err != nil {
+				return c, fmt.Errorf("%s: %s: %w", name, key, err)
+			}
 		}
+	}
+
+	for i, field := range fields {
+		env := field.Tag.Get("env")
 
-		return i, ok, nil
+		if val, ok := os.LookupEnv(env); ok {
+			if err := ykm.SetOption(values[i], val); err != nil {
+				return c, fmt.Errorf("%s: %w", env, err)
+			}
+		}
 	}
 
-	return def, false, nil
+	var err error
+
+	if fs != nil {
+		fs.Visit(func(f *flag.Flag) {
+			cf, ok := flags[f.Name]
+
+			if !ok || err != nil {
+				return
+			}
+
+			if e := ykm.SetOption(values[index[strings.ReplaceAll(f.Name, "-", "_")]], cf.value); e != nil {
+				err = fmt.Errorf("-%s: %w", f.Name, e)
+			}
+		})
+	}
+
+	return c, err
 }
 
-func parseEnvBool(key string, def bool) (bool, bool, error) {
-	if val, ok := os.LookupEnv(key); ok {
-		b, err := strconv.ParseBool(val)
+func readConfigFile(name string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(name)
 
-		if err != nil {
-			return def, false, err
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	values := make(map[string]interface{})
+
+	switch filepath.Ext(name) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+
+		dec.UseNumber()
+
+		if err := dec.Decode(&values); err != nil {
+			return nil, fmt.Errorf("error decoding config file: %w", err)
 		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("error decoding config file: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown config file format: %s", name)
+	}
 
-		return b, ok, nil
+	return values, nil
+}
+
+func (c Configuration) Validate(mode string) error {
+	errs := c.Options.Check()
+
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Sprintf(format, args...))
+		}
+	}
+
+	oneOf := func(val string, options ...string) bool {
+		for _, o := range options {
+			if val == o {
+				return true
+			}
+		}
+
+		return false
 	}
 
-	return def, false, nil
+	check(c.CrossCheckTolerance >= 0, "CROSS_CHECK_TOLERANCE must not be negative")
+
+	check(c.ParaphraseThreshold >= 0, "PARAPHRASE_THRESHOLD must not be negative")
+	check(oneOf(c.ScoreNormalization, ykm.NormalizationRaw, ykm.NormalizationToken, ykm.NormalizationTree, ykm.NormalizationBaseline), "unknown SCORE_NORMALIZATION: %s", c.ScoreNormalization)
+
+	check(c.GenerationBeamWidth >= 1, "GENERATION_BEAM_WIDTH must be positive")
+	check(c.GenerationTopK >= 1, "GENERATION_TOP_K must be positive")
+
+	check(c.ShardCount >= 1, "SHARD_COUNT must be positive")
+	check(c.ShardIndex >= 0 && c.ShardIndex < c.ShardCount, "SHARD_INDEX must be in [0, SHARD_COUNT)")
+
+	check(mode != ModeTrainShard || c.CountsOutputPath != "", "%s requires COUNTS_OUTPUT_PATH", mode)
+	check(mode != ModeMerge || c.CountsInputPath != "", "%s requires COUNTS_INPUT_PATH", mode)
+
+	if oneOf(mode, ModeTrain, ModeTrainShard, ModeEvaluate, ModeCrossCheck, ModeBenchmark, ModeViterbi, ModeScore) {
+		check(c.TrainingDataPath != "", "%s requires TRAINING_DATA_PATH", mode)
+	}
+
+	check(mode != ModeServe || c.ServeAddress != "", "%s requires SERVE_ADDRESS", mode)
+
+	if oneOf(mode, ModeEvaluate, ModeExplore, ModeViterbi, ModeGenerate, ModeConvert, ModeServe, ModeScore, ModeChecksum) {
+		check(c.InitModelPath != "", "%s requires INIT_MODEL_PATH", mode)
+	}
+
+	if len(errs) > 0 {
+		return errors.New("invalid configuration:\n  " + strings.Join(errs, "\n  "))
+	}
+
+	return nil
+}
+
+func loadModel() *ykm.Model {
+	fmt.Println("Importing model...")
+
+	m, err := ykm.LoadModel(Config.InitModelPath, Config.Options)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	printWarnings(m)
+
+	return m
+}
+
+func printWarnings(m *ykm.Model) {
+	for _, w := range m.Warnings() {
+		log.Printf("Warning: %s", w)
+	}
 }
 
 func ensureDirectoryExists(name string) {
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"mono-ymk/ykm"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigDefaults(t *testing.T) {
+	c, err := LoadConfig("", nil, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(c.Options, ykm.DefaultOptions()) {
+		t.Errorf("defaults of the configuration differ from the default options:\n%+v\n%+v", c.Options, ykm.DefaultOptions())
+	}
+
+	if c.ScoreNormalization != ykm.NormalizationRaw || c.GenerationBeamWidth != 10 {
+		t.Errorf("unexpected defaults: SCORE_NORMALIZATION=%s GENERATION_BEAM_WIDTH=%d", c.ScoreNormalization, c.GenerationBeamWidth)
+	}
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "config.yaml")
+
+	if err := os.WriteFile(name, []byte("generation_top_k: 2\ngeneration_beam_width: 3\nlanguage_model_weight: 4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("GENERATION_BEAM_WIDTH", "30")
+	t.Setenv("LANGUAGE_MODEL_WEIGHT", "40")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+
+	flags := RegisterConfigFlags(fs)
+
+	if err := fs.Parse([]string{"-language-model-weight", "400"}); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadConfig(name, fs, flags)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.GenerationTopK != 2 || c.GenerationBeamWidth != 30 || c.LanguageModelWeight != 400 {
+		t.Errorf("got top k %d, beam width %d, weight %v, want 2, 30, 400", c.GenerationTopK, c.GenerationBeamWidth, c.LanguageModelWeight)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"unknown.json": `{"no_such_setting": 1}`,
+		"invalid.json": `{"generation_top_k": "many"}`,
+		"config.toml":  `generation_top_k = 1`,
+	}
+
+	for file, content := range files {
+		name := filepath.Join(dir, file)
+
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := LoadConfig(name, nil, nil); err == nil {
+			t.Errorf("%s: expected an error", file)
+		}
+	}
+
+	t.Setenv("ENABLE_EARLY_STOPPING", "sometimes")
+
+	if _, err := LoadConfig("", nil, nil); err == nil || !strings.Contains(err.Error(), "ENABLE_EARLY_STOPPING") {
+		t.Errorf("expected an error naming ENABLE_EARLY_STOPPING, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		mode      string
+		configure func(*Configuration)
+		err       string
+	}{
+		{ModeTrain, func(c *Configuration) {}, ""},
+		{ModeTrain, func(c *Configuration) { c.TrainingDataPath = "" }, "requires TRAINING_DATA_PATH"},
+		{ModeMerge, func(c *Configuration) { c.TrainingDataPath = ""; c.CountsInputPath = "counts.gob" }, ""},
+		{ModeMerge, func(c *Configuration) {}, "requires COUNTS_INPUT_PATH"},
+		{ModeEvaluate, func(c *Configuration) {}, "requires INIT_MODEL_PATH"},
+		{ModeEvaluate, func(c *Configuration) { c.InitModelPath = "model.gob" }, ""},
+		{ModeTrain, func(c *Configuration) { c.EnablePhrasalTranslations = true }, "requires PHRASE_LENGTH_LIMIT > 0"},
+		{ModeTrain, func(c *Configuration) { c.EnablePhrasalTranslations = true; c.PhraseLengthLimit = 2 }, ""},
+		{ModeTrain, func(c *Configuration) { c.ScoreNormalization = "none" }, "unknown SCORE_NORMALIZATION"},
+		{ModeTrain, func(c *Configuration) { c.ShardIndex = 1 }, "SHARD_INDEX must be in [0, SHARD_COUNT)"},
+		{ModeTrain, func(c *Configuration) { c.EnableEarlyStopping = true }, "requires HELD_OUT_DATA_PATH"},
+		{ModeTrain, func(c *Configuration) { c.ResumeCheckpoint = true; c.InitModelPath = "model.gob" }, "mutually exclusive"},
+	}
+
+	for _, tt := range tests {
+		c, err := LoadConfig("", nil, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c.TrainingDataPath = "corpus.tsv"
+
+		tt.configure(&c)
+
+		err = c.Validate(tt.mode)
+
+		if tt.err == "" && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.mode, err)
+		}
+
+		if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("%s: expected an error containing %q, got %v", tt.mode, tt.err, err)
+		}
+	}
+}
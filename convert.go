@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"mono-ymk/ykm"
+	"path/filepath"
+	"strings"
+)
+
+func ConvertModel() {
+	m := loadModel()
+
+	// the training config of legacy models is unknown, so it is left zero
+	if m.Info().Version == 0 {
+		m.SetInfo(ykm.ModelInfo{
+			Iteration: Config.InitModelIteration,
+			Converted: true,
+		})
+	}
+
+	stub := filepath.Base(Config.InitModelPath)
+	stub = strings.TrimSuffix(stub, filepath.Ext(stub))
+
+	if err := ykm.Export(m, strings.TrimPrefix(stub, "model_")); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Converted model %s (format: %s)\n", Config.InitModelPath, Config.ModelExportFormat)
+}
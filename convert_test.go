@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/gob"
+	"mono-ymk/ykm"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConvertModel(t *testing.T) {
+	m := trainMock(t)
+
+	dir := t.TempDir()
+
+	for table, values := range m.Tables() {
+		f, err := os.Create(filepath.Join(dir, "model_3-"+table+".gob"))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := gob.NewEncoder(f).Encode(values); err != nil {
+			t.Fatal(err)
+		}
+
+		f.Close()
+	}
+
+	Config.InitModelPath = filepath.Join(dir, "model_3")
+	Config.InitModelIteration = 3
+	Config.ModelExportDirectory = dir
+
+	ConvertModel()
+
+	converted, err := ykm.LoadModel(filepath.Join(dir, "model_3.gob"), Config.Options)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info := converted.Info(); !info.Converted || info.Iteration != 3 || info.Version != ykm.ModelFormatVersion {
+		t.Errorf("unexpected info of the converted model: %+v", info)
+	}
+
+	if len(converted.Warnings()) != 0 {
+		t.Errorf("got warnings %v for the converted model", converted.Warnings())
+	}
+}
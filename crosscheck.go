@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"mono-ymk/ykm"
+	"os"
+)
+
+func CrossCheck() {
+	m := ykm.NewModel(Config.Options)
+
+	if Config.InitModelPath != "" {
+		m = loadModel()
+	}
+
+	if err := m.Prepare(Config.TrainingDataPath); err != nil {
+		log.Fatal(err)
+	}
+
+	counted, mismatches, err := ykm.CrossCheck(m, Config.TrainingDataPath, Config.CrossCheckTolerance, os.Stdout)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if mismatches > 0 {
+		log.Fatalf("Found %d mismatches between arithmetic backends", mismatches)
+	}
+
+	fmt.Printf("\nArithmetic backends agree on %d samples (tolerance: %e)\n", counted, Config.CrossCheckTolerance)
+}
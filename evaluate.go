@@ -2,36 +2,60 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"golang.org/x/sync/semaphore"
+	"io"
 	"log"
 	"math/big"
+	"mono-ymk/ykm"
 	"sync"
 )
 
 func Evaluate() {
-	if m, err := importModel(Config.InitModelPath); err != nil {
+	model := loadModel()
+
+	corpus, err := ykm.NewIterator(Config.TrainingDataPath, Config.CorpusFormat)
+
+	if err != nil {
 		log.Fatal(err)
-	} else {
-		model = m
 	}
 
+	defer corpus.Close()
+
 	Verify(model, big.NewFloat(1e-5))
 
-	tp := 0
-	fp := 0
-	tn := 0
-	fn := 0
+	score, err := ykm.NewScoreFunction(Config.ScoreNormalization, model)
 
-	pth := big.NewFloat(Config.ParaphraseThreshold)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	counter := 0
+	var out *PredictionWriter
+
+	if Config.PredictionOutputPath != "" {
+		if out, err = NewPredictionWriter(Config.PredictionOutputPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	agg := NewAggregator()
+
+	add := func(p Prediction) {
+		agg.Add(p)
 
-	numPos := 0
-	numNeg := 0
+		if out == nil {
+			return
+		}
+
+		if err := out.Write(p); err != nil {
+			log.Fatalf("Error writing prediction %s: %v", p.ID, err)
+		}
+	}
+
+	lth := ykm.LogOf(big.NewFloat(Config.ParaphraseThreshold))
 
-	pos := new(big.Float)
-	neg := new(big.Float)
+	counter := 0
 
 	ctx := context.TODO()
 	sem := semaphore.NewWeighted(int64(Config.ConcurrentSampleEvaluations))
@@ -41,9 +65,17 @@ func Evaluate() {
 	for corpus.Next() && (Config.TrainingSampleLimit == -1 || counter < Config.TrainingSampleLimit) {
 		sample := corpus.Sample()
 
-		mt, e, err := initSample(sample)
+		if !sample.Labeled {
+			add(SkippedPrediction(sample, errors.New("missing label")))
+
+			continue
+		}
+
+		mt, e, err := model.InitSample(sample)
 
 		if err != nil {
+			add(SkippedPrediction(sample, err))
+
 			continue
 		}
 
@@ -57,43 +89,25 @@ func Evaluate() {
 			defer sem.Release(1)
 			defer wg.Done()
 
-			g, err := NewGraph(mt, e, model)
+			g, err := ykm.NewGraph(mt, e, model)
 
 			if err != nil {
-				return
-			}
-
-			p := g.pBeta[g.nodes[0]]
-
-			if sample.Label && p.Cmp(pth) == 1 {
-				tp++
-			}
-
-			if !sample.Label && p.Cmp(pth) == 1 {
-				fp++
-			}
-
-			if !sample.Label && p.Cmp(pth) == -1 {
-				tn++
-			}
-
-			if sample.Label && p.Cmp(pth) == -1 {
-				fn++
-			}
+				add(SkippedPrediction(sample, err))
 
-			fmt.Printf("TP: %d FP: %d TN: %d FN: %d (%e) [%s %t]\n", tp, fp, tn, fn, p, sample.ID, sample.Label)
-
-			if p.Cmp(new(big.Float)) == 0 {
 				return
 			}
 
-			if sample.Label {
-				pos.Add(pos, p)
-				numPos++
-			} else {
-				neg.Add(neg, p)
-				numNeg++
-			}
+			s := score(g.LogProbability(), mt, e)
+
+			add(Prediction{
+				ID:             sample.ID,
+				Label:          sample.Label,
+				Probability:    g.Probability(),
+				LogProbability: ykm.JSONFloat(g.LogProbability()),
+				Score:          ykm.JSONFloat(s),
+				Predicted:      Predict(s, lth),
+				Meta:           sample.Meta,
+			})
 		}()
 
 		counter++
@@ -101,23 +115,34 @@ func Evaluate() {
 
 	wg.Wait()
 
-	precision := float64(tp) / float64(tp+fp)
-	recall := float64(tp) / float64(tp+fn)
+	if err := corpus.Error(); err != nil && err != io.EOF {
+		log.Fatalf("Error reading %s: %v", Config.TrainingDataPath, err)
+	}
+
+	if out != nil {
+		if err := out.Close(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	fmt.Printf("\n%s", agg.Summary())
 
-	f := float64(2) * precision * recall / (precision + recall)
+	report := Sweep(agg.Scores(), lth)
 
-	avgPos := pos.Quo(pos, big.NewFloat(float64(numPos)))
-	avgNeg := neg.Quo(neg, big.NewFloat(float64(numNeg)))
+	report.Normalization = Config.ScoreNormalization
 
-	mean := new(big.Float)
+	fmt.Printf("Normalization: %s\n", report.Normalization)
 
-	mean.Add(mean, avgPos).Add(mean, avgNeg).Quo(mean, big.NewFloat(2))
+	fmt.Printf("ROC-AUC: %e AP: %e\n", report.ROCAUC, report.AveragePrecision)
+	fmt.Printf("F1-optimal threshold: %s (F1: %e)\n", report.F1Optimal.Threshold, report.F1Optimal.F1)
+	fmt.Printf("Accuracy-optimal threshold: %s (Accuracy: %e)\n", report.AccuracyOptimal.Threshold, report.AccuracyOptimal.Accuracy)
 
-	fmt.Printf("Precision: %e Recall: %e F1: %e\n", precision, recall, f)
-	fmt.Printf("AvgPos: %e AvgNeg: %e Mean: %e\n", avgPos, avgNeg, mean)
+	if err := report.Write(Config.EvaluationReportPath); err != nil {
+		log.Fatal(err)
+	}
 }
 
-func Verify(model *Model, threshold *big.Float) {
+func Verify(model *ykm.Model, threshold *big.Float) {
 	verifyTable := func(table map[string]map[string]*big.Float) {
 		for k, v := range table {
 			sum := new(big.Float)
@@ -135,7 +160,9 @@ func Verify(model *Model, threshold *big.Float) {
 		}
 	}
 
-	verifyTable(model.n)
-	verifyTable(model.r)
-	verifyTable(model.t)
+	tables := model.Tables()
+
+	verifyTable(tables["n"])
+	verifyTable(tables["r"])
+	verifyTable(tables["t"])
 }
@@ -3,10 +3,8 @@ package main
 import (
 	"bufio"
 	"fmt"
-	"log"
 	"math/big"
 	"os"
-	"reflect"
 	"sort"
 )
 
@@ -30,11 +28,8 @@ func (ps pairs) Swap(i, j int) {
 }
 
 func Explore() {
-	if m, err := importModel(Config.InitModelPath); err != nil {
-		log.Fatal(err)
-	} else {
-		model = m
-	}
+	model := loadModel()
+	tables := model.Tables()
 
 	sum := func(m map[string]*big.Float) *big.Float {
 		sum := new(big.Float)
@@ -82,31 +77,28 @@ func Explore() {
 
 		if table == "" {
 			if text == "$$" {
-				t := reflect.ValueOf(model).Elem().Type()
+				names := make([]string, 0, len(tables))
 
-				for i := 0; i < t.NumField(); i++ {
-					fmt.Println(t.Field(i).Name)
+				for name := range tables {
+					names = append(names, name)
+				}
+
+				sort.Strings(names)
+
+				for _, name := range names {
+					fmt.Println(name)
 				}
 
 				continue
 			}
 
-			switch text {
-			case "n":
-				t = model.n
-			case "r":
-				t = model.r
-			case "t":
-				t = model.t
-			case "l":
-				t = model.l
-			case "f":
-				t = model.f
-			default:
+			if _, ok := tables[text]; !ok {
 				fmt.Println("unknown table")
 				continue
 			}
 
+			t = tables[text]
+
 			table = text
 			continue
 		}
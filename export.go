@@ -1,27 +0,0 @@
-package main
-
-import (
-	"encoding/gob"
-	"fmt"
-	"math/big"
-	"os"
-	"path/filepath"
-	"strings"
-)
-
-func Export(t map[string]map[string]*big.Float, stubs ...string) error {
-	name := fmt.Sprintf("model_%s.gob", strings.Join(stubs, "-"))
-	file, err := os.Create(filepath.Join(Config.ModelExportDirectory, name))
-
-	if err != nil {
-		return fmt.Errorf("error creating file: %w", err)
-	}
-
-	enc := gob.NewEncoder(file)
-
-	if err := enc.Encode(t); err != nil {
-		return fmt.Errorf("error encoding table: %w", err)
-	}
-
-	return nil
-}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"mono-ymk/ykm"
+	"path/filepath"
+	"testing"
+)
+
+const mockCorpus = "test/mono-ykm_mock.tsv"
+
+// mockConfig sets Config to the defaults for the mock corpus.
+func mockConfig(tb testing.TB) {
+	tb.Helper()
+
+	c, err := LoadConfig("", nil, nil)
+
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	c.TrainingDataPath = mockCorpus
+	c.ExportModel = false
+
+	Config = c
+}
+
+// trainMock trains a model on the mock corpus and sets Config to match it.
+func trainMock(tb testing.TB) *ykm.Model {
+	tb.Helper()
+
+	mockConfig(tb)
+
+	m, err := ykm.NewTrainer(Config.Options).Train()
+
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return m
+}
+
+// exportMock trains and exports a model on the mock corpus and sets Config to
+// load it. It returns the export directory.
+func exportMock(tb testing.TB) string {
+	tb.Helper()
+
+	dir := tb.TempDir()
+
+	mockConfig(tb)
+
+	Config.ExportModel = true
+	Config.ModelExportDirectory = dir
+
+	if _, err := ykm.NewTrainer(Config.Options).Train(); err != nil {
+		tb.Fatal(err)
+	}
+
+	Config.InitModelPath = filepath.Join(dir, "model_1.gob")
+
+	return dir
+}
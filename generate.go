@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mono-ymk/ykm"
+	"os"
+	"strings"
+)
+
+func Generate() {
+	model := loadModel()
+
+	var lm *ykm.LanguageModel
+
+	if Config.LanguageModelPath != "" {
+		fmt.Println("Loading language model...")
+
+		if l, err := ykm.LoadARPA(Config.LanguageModelPath); err != nil {
+			log.Fatal(err)
+		} else {
+			lm = l
+		}
+	}
+
+	var in io.Reader = os.Stdin
+
+	if Config.GenerationInputPath != "" {
+		f, err := os.Open(Config.GenerationInputPath)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		defer f.Close()
+
+		in = f
+	}
+
+	out, err := os.Create(Config.GenerationOutputPath)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer out.Close()
+
+	enc := json.NewEncoder(out)
+	gen := ykm.NewGenerator(model, lm, Config.LanguageModelWeight, Config.GenerationBeamWidth)
+
+	scanner := bufio.NewScanner(in)
+
+	if Config.TreeFormat == ykm.FormatCoNLLU {
+		scanner.Split(scanBlocks)
+	}
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" {
+			continue
+		}
+
+		t, err := ykm.DecodeTree(line, Config.TreeFormat)
+
+		if err != nil {
+			fmt.Printf("Skipped tree %s (%s)\n", line, err)
+
+			continue
+		}
+
+		candidates := gen.Generate(t, Config.GenerationTopK)
+
+		if err := enc.Encode(ykm.Generation{Tree: line, Candidates: candidates}); err != nil {
+			log.Fatalf("Error writing candidates: %v", err)
+		}
+
+		fmt.Printf("Generated %d candidates for %s\n", len(candidates), line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func scanBlocks(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.Index(data, []byte("\n\n")); i >= 0 {
+		return i + 2, data[:i], nil
+	}
+
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
@@ -6,4 +6,5 @@ require (
 	github.com/jonasknobloch/jinn v0.6.0
 	golang.org/x/sync v0.0.0-20190423024810-112230192c58
 	gonum.org/v1/gonum v0.9.3
+	gopkg.in/yaml.v3 v3.0.1
 )
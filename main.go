@@ -2,20 +2,109 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"runtime/pprof"
+	"sort"
+	"strings"
 )
 
-var cpuProfile = flag.String("cpuprofile", "", "write cpu profile to file")
-var execMode = flag.String("m", "train", "choose execution mode")
+var execMode = ModeTrain
 
 const ModeTrain = "train"
 const ModeEvaluate = "evaluate"
 const ModeExplore = "explore"
+const ModeCrossCheck = "crosscheck"
+const ModeViterbi = "viterbi"
+const ModeGenerate = "generate"
+const ModeConvert = "convert"
+const ModeServe = "serve"
+const ModeScore = "score"
+const ModeTrainShard = "train-shard"
+const ModeMerge = "merge"
+const ModeBenchmark = "benchmark"
+const ModeChecksum = "checksum"
+
+var modes = map[string]func(){
+	ModeTrain:      Train,
+	ModeEvaluate:   Evaluate,
+	ModeExplore:    Explore,
+	ModeCrossCheck: CrossCheck,
+	ModeViterbi:    Viterbi,
+	ModeGenerate:   Generate,
+	ModeConvert:    ConvertModel,
+	ModeServe:      Serve,
+	ModeScore:      Score,
+	ModeTrainShard: TrainShard,
+	ModeMerge:      Merge,
+	ModeBenchmark:  Benchmark,
+	ModeChecksum:   Checksum,
+}
+
+func usage(fs *flag.FlagSet) func() {
+	return func() {
+		names := make([]string, 0, len(modes))
+
+		for name := range modes {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		fmt.Fprintf(fs.Output(), "Usage: %s <%s> [flags]\n\n", os.Args[0], strings.Join(names, "|"))
+		fmt.Fprintf(fs.Output(), "Settings are read from defaults, the -config file, environment variables and flags (in increasing precedence).\n\n")
+
+		fs.PrintDefaults()
+	}
+}
 
 func main() {
-	flag.Parse()
+	args := os.Args[1:]
+
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		execMode, args = args[0], args[1:]
+	}
+
+	fs := flag.NewFlagSet(execMode, flag.ExitOnError)
+
+	cpuProfile := fs.String("cpuprofile", "", "write cpu profile to file")
+	configPath := fs.String("config", "", "read settings from JSON or YAML file")
+	legacyMode := fs.String("m", "", "choose execution mode (deprecated, use subcommands)")
+
+	flags := RegisterConfigFlags(fs)
+
+	fs.Usage = usage(fs)
+
+	_ = fs.Parse(args)
+
+	if *legacyMode != "" {
+		execMode = *legacyMode
+	}
+
+	run, ok := modes[execMode]
+
+	if !ok {
+		fs.Usage()
+		log.Fatalf("unknown execution mode: %s", execMode)
+	}
+
+	c, err := LoadConfig(*configPath, fs, flags)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := c.Validate(execMode); err != nil {
+		log.Fatal(err)
+	}
+
+	Config = c
+
+	fmt.Printf("%+v\n\n", &Config)
+
+	ensureDirectoryExists(Config.GraphExportDirectory)
+	ensureDirectoryExists(Config.ModelExportDirectory)
 
 	if *cpuProfile != "" {
 		f, err := os.Create(*cpuProfile)
@@ -31,12 +120,5 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
-	switch *execMode {
-	case ModeTrain:
-		TrainEM(Config.TrainingIterationLimit, Config.TrainingSampleLimit)
-	case ModeEvaluate:
-		Evaluate()
-	case ModeExplore:
-		Explore()
-	}
+	run()
 }
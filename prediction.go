@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"mono-ymk/ykm"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+type Prediction struct {
+	ID             string        `json:"id"`
+	Label          bool          `json:"label"`
+	Probability    *big.Float    `json:"probability"`
+	LogProbability ykm.JSONFloat `json:"log_probability"`
+	Score          ykm.JSONFloat `json:"score"`
+	Predicted      bool          `json:"predicted"`
+	Skip           string        `json:"skip,omitempty"`
+
+	Meta map[string]interface{} `json:"meta,omitempty"`
+}
+
+func SkippedPrediction(sample *ykm.Sample, err error) Prediction {
+	return Prediction{
+		ID:             sample.ID,
+		Label:          sample.Label,
+		LogProbability: ykm.JSONFloat(math.NaN()),
+		Score:          ykm.JSONFloat(math.NaN()),
+		Skip:           err.Error(),
+		Meta:           sample.Meta,
+	}
+}
+
+func (p Prediction) Record() []string {
+	if p.Skip != "" {
+		return []string{p.ID, strconv.FormatBool(p.Label), "", "", "", "", p.Skip}
+	}
+
+	return []string{
+		p.ID,
+		strconv.FormatBool(p.Label),
+		p.Probability.Text('e', 6),
+		strconv.FormatFloat(float64(p.LogProbability), 'g', -1, 64),
+		strconv.FormatFloat(float64(p.Score), 'g', -1, 64),
+		strconv.FormatBool(p.Predicted),
+		"",
+	}
+}
+
+type PredictionWriter struct {
+	file *os.File
+	buf  *bufio.Writer
+	enc  *json.Encoder
+	mu   sync.Mutex
+}
+
+func NewPredictionWriter(name string) (*PredictionWriter, error) {
+	f, err := os.Create(name)
+
+	if err != nil {
+		return nil, fmt.Errorf("error creating file: %w", err)
+	}
+
+	pw := &PredictionWriter{
+		file: f,
+		buf:  bufio.NewWriter(f),
+	}
+
+	if filepath.Ext(name) == ".jsonl" {
+		pw.enc = json.NewEncoder(pw.buf)
+
+		return pw, nil
+	}
+
+	if _, err := pw.buf.WriteString("ID\tLabel\tProbability\tLogProbability\tScore\tPredicted\tSkip\n"); err != nil {
+		return nil, fmt.Errorf("error writing header: %w", err)
+	}
+
+	return pw, nil
+}
+
+func (pw *PredictionWriter) Write(p Prediction) error {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	if pw.enc != nil {
+		return pw.enc.Encode(p)
+	}
+
+	for i, field := range p.Record() {
+		if i > 0 {
+			pw.buf.WriteByte('\t')
+		}
+
+		pw.buf.WriteString(field)
+	}
+
+	return pw.buf.WriteByte('\n')
+}
+
+func (pw *PredictionWriter) Close() error {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	if err := pw.buf.Flush(); err != nil {
+		return err
+	}
+
+	return pw.file.Close()
+}
+
+type Aggregator struct {
+	tp, fp, tn, fn int
+	skipped        int
+
+	numPos, numNeg int
+	pos, neg       *big.Float
+
+	scores []ScoredSample
+
+	mu sync.Mutex
+}
+
+func NewAggregator() *Aggregator {
+	return &Aggregator{
+		pos:    new(big.Float),
+		neg:    new(big.Float),
+		scores: make([]ScoredSample, 0),
+	}
+}
+
+func (a *Aggregator) Add(p Prediction) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if p.Skip != "" {
+		a.skipped++
+
+		fmt.Printf("Skipped sample %s (%s)\n", p.ID, p.Skip)
+
+		return
+	}
+
+	a.scores = append(a.scores, ScoredSample{Score: float64(p.Score), Label: p.Label})
+
+	switch {
+	case p.Label && p.Predicted:
+		a.tp++
+	case !p.Label && p.Predicted:
+		a.fp++
+	case !p.Label && !p.Predicted:
+		a.tn++
+	default:
+		a.fn++
+	}
+
+	fmt.Printf("TP: %d FP: %d TN: %d FN: %d (%e) [%s %t]\n", a.tp, a.fp, a.tn, a.fn, p.Probability, p.ID, p.Label)
+
+	if p.Probability.Sign() == 0 {
+		return
+	}
+
+	if p.Label {
+		a.pos.Add(a.pos, p.Probability)
+		a.numPos++
+	} else {
+		a.neg.Add(a.neg, p.Probability)
+		a.numNeg++
+	}
+}
+
+func (a *Aggregator) Summary() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// the operating point reports zero for undefined ratios
+	op := NewOperatingPoint(0, a.tp, a.fp, a.tn, a.fn)
+
+	avgPos := new(big.Float)
+	avgNeg := new(big.Float)
+
+	if a.numPos > 0 {
+		avgPos.Quo(a.pos, big.NewFloat(float64(a.numPos)))
+	}
+
+	if a.numNeg > 0 {
+		avgNeg.Quo(a.neg, big.NewFloat(float64(a.numNeg)))
+	}
+
+	mean := new(big.Float)
+
+	mean.Add(mean, avgPos).Add(mean, avgNeg).Quo(mean, big.NewFloat(2))
+
+	return fmt.Sprintf("TP: %d FP: %d TN: %d FN: %d Skipped: %d\nPrecision: %e Recall: %e F1: %e\nAvgPos: %e AvgNeg: %e Mean: %e\n",
+		a.tp, a.fp, a.tn, a.fn, a.skipped, op.Precision, op.Recall, op.F1, avgPos, avgNeg, mean)
+}
+
+func (a *Aggregator) Scores() []ScoredSample {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.scores
+}
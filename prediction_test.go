@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"mono-ymk/ykm"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAggregatorSummary(t *testing.T) {
+	agg := NewAggregator()
+
+	if s := agg.Summary(); strings.Contains(s, "NaN") {
+		t.Errorf("summary of no predictions contains NaN:\n%s", s)
+	}
+
+	agg.Add(SkippedPrediction(&ykm.Sample{ID: "skip"}, errors.New("invalid tree")))
+
+	if s := agg.Summary(); strings.Contains(s, "NaN") || !strings.Contains(s, "Skipped: 1") {
+		t.Errorf("unexpected summary of a skipped prediction:\n%s", s)
+	}
+
+	for _, p := range []Prediction{
+		{ID: "tp", Label: true, Predicted: true, Probability: big.NewFloat(0.5)},
+		{ID: "fp", Label: false, Predicted: true, Probability: big.NewFloat(0.25)},
+		{ID: "fn", Label: true, Predicted: false, Probability: big.NewFloat(0)},
+	} {
+		agg.Add(p)
+	}
+
+	want := "TP: 1 FP: 1 TN: 0 FN: 1 Skipped: 1\nPrecision: 5.000000e-01 Recall: 5.000000e-01 F1: 5.000000e-01\n"
+
+	if s := agg.Summary(); !strings.HasPrefix(s, want) {
+		t.Errorf("got summary:\n%s\nwant prefix:\n%s", s, want)
+	}
+}
+
+func TestEvaluatePredictions(t *testing.T) {
+	dir := exportMock(t)
+
+	Config.TrainingDataPath = filepath.Join(dir, "evaluate.tsv")
+	Config.EvaluationReportPath = filepath.Join(dir, "evaluation.json")
+
+	corpus := "ID\tTree\tSentence\tLabel\n" +
+		"foo\t(σ (γ α) (γ β))\ts b a\t1\n" +
+		"bar\t(σ (s (γ b) (γ a)))\tα β\t1\n" +
+		"zero\t(σ (γ α) (γ β))\ts b zzz\t0\n" +
+		"broken\t(σ (γ α)\ts\t0\n"
+
+	if err := os.WriteFile(Config.TrainingDataPath, []byte(corpus), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ext := range []string{".tsv", ".jsonl"} {
+		Config.PredictionOutputPath = filepath.Join(dir, "predictions"+ext)
+
+		Evaluate()
+
+		data, err := os.ReadFile(Config.PredictionOutputPath)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+
+		if ext == ".tsv" {
+			lines = lines[1:]
+		}
+
+		skipped := make(map[string]bool)
+
+		for _, line := range lines {
+			var p Prediction
+
+			if ext == ".jsonl" {
+				if err := json.Unmarshal([]byte(line), &p); err != nil {
+					t.Fatalf("invalid prediction %s: %v", line, err)
+				}
+			} else {
+				fields := strings.Split(line, "\t")
+
+				p.ID, p.Skip = fields[0], fields[6]
+			}
+
+			skipped[p.ID] = p.Skip != ""
+		}
+
+		if len(skipped) != 4 || !skipped["broken"] || skipped["foo"] || skipped["bar"] || skipped["zero"] {
+			t.Errorf("%s: got predictions %v", ext, skipped)
+		}
+	}
+
+	data, err := os.ReadFile(Config.EvaluationReportPath)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var r SweepReport
+
+	if err := json.Unmarshal(data, &r); err != nil {
+		t.Fatal(err)
+	}
+
+	// the zero-probability negative ranks below both positives
+	if r.Samples != 3 || r.Positives != 2 || r.Negatives != 1 || r.ROCAUC != 1 {
+		t.Errorf("unexpected report: %+v", r)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"golang.org/x/sync/semaphore"
+	"io"
+	"log"
+	"math"
+	"mono-ymk/ykm"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type scoreRow struct {
+	id      string
+	logProb float64
+	score   float64
+	status  string
+}
+
+func (r scoreRow) String() string {
+	status := strings.NewReplacer("\t", " ", "\n", " ").Replace(r.status)
+
+	return fmt.Sprintf("%s\t%s\t%s\t%s\n", r.id, strconv.FormatFloat(r.logProb, 'g', -1, 64), strconv.FormatFloat(r.score, 'g', -1, 64), status)
+}
+
+func Score() {
+	model := loadModel()
+
+	scorer, err := ykm.NewScorer(model, Config.ScoreNormalization)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	corpus, err := ykm.NewIterator(Config.TrainingDataPath, Config.CorpusFormat)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer corpus.Close()
+
+	f, err := os.Create(Config.ScoreOutputPath)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+
+	if _, err := w.WriteString("ID\tlogprob\tscore\tstatus\n"); err != nil {
+		log.Fatal(err)
+	}
+
+	queue := make(chan chan scoreRow, 2*Config.ConcurrentSampleEvaluations)
+	done := make(chan error)
+
+	go func() {
+		var err error
+
+		for slot := range queue {
+			row := <-slot
+
+			if err == nil {
+				_, err = w.WriteString(row.String())
+			}
+		}
+
+		if err == nil {
+			err = w.Flush()
+		}
+
+		done <- err
+	}()
+
+	counter := 0
+
+	ctx := context.TODO()
+	sem := semaphore.NewWeighted(int64(Config.ConcurrentSampleEvaluations))
+
+	for corpus.Next() && (Config.TrainingSampleLimit == -1 || counter < Config.TrainingSampleLimit) {
+		sample := corpus.Sample()
+
+		slot := make(chan scoreRow, 1)
+
+		queue <- slot
+
+		if err := sem.Acquire(ctx, 1); err != nil {
+			log.Fatalf("Failed to acquire semaphore: %v", err)
+		}
+
+		go func() {
+			defer sem.Release(1)
+
+			row := scoreRow{id: sample.ID, logProb: math.NaN(), score: math.NaN(), status: "ok"}
+
+			if r, err := scorer.Score(sample, false); err != nil {
+				row.status = err.Error()
+			} else {
+				row.logProb = r.LogProbability
+				row.score = r.Score
+			}
+
+			slot <- row
+		}()
+
+		counter++
+	}
+
+	close(queue)
+
+	if err := <-done; err != nil {
+		log.Fatalf("Error writing scores: %v", err)
+	}
+
+	if err := corpus.Error(); err != nil && err != io.EOF {
+		log.Fatalf("Error reading %s: %v", Config.TrainingDataPath, err)
+	}
+
+	fmt.Printf("Scored %d samples\n", counter)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"mono-ymk/ykm"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// runScore runs score mode and returns the rows of the output file.
+func runScore(t *testing.T, dir string) [][]string {
+	t.Helper()
+
+	Config.ScoreOutputPath = filepath.Join(dir, "scores.tsv")
+
+	Score()
+
+	data, err := os.ReadFile(Config.ScoreOutputPath)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := make([][]string, 0)
+
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		rows = append(rows, strings.Split(line, "\t"))
+	}
+
+	return rows
+}
+
+func TestScoreNormalization(t *testing.T) {
+	dir := exportMock(t)
+
+	tokens := map[string]float64{"foo": 3, "bar": 2}
+
+	for _, normalization := range []string{ykm.NormalizationRaw, ykm.NormalizationToken, ykm.NormalizationBaseline} {
+		Config.ScoreNormalization = normalization
+
+		rows := runScore(t, dir)
+
+		if strings.Join(rows[0], " ") != "ID logprob score status" {
+			t.Fatalf("unexpected header: %v", rows[0])
+		}
+
+		for _, row := range rows[1:] {
+			logp, _ := strconv.ParseFloat(row[1], 64)
+			score, _ := strconv.ParseFloat(row[2], 64)
+
+			want := logp
+
+			if normalization == ykm.NormalizationToken {
+				want = logp / tokens[row[0]]
+			}
+
+			// the baseline assigns every sample a probability below 1
+			if normalization == ykm.NormalizationBaseline && score > logp {
+				continue
+			}
+
+			if math.Abs(score-want) > 1e-12 {
+				t.Errorf("%s score of sample %s = %v, want %v", normalization, row[0], score, want)
+			}
+		}
+	}
+}
+
+func TestScoreOrder(t *testing.T) {
+	dir := exportMock(t)
+
+	var sb strings.Builder
+
+	sb.WriteString("ID\tTree\tSentence\n")
+
+	ids := make([]string, 0)
+
+	for i := 0; i < 60; i++ {
+		id := fmt.Sprintf("s%02d", i)
+
+		switch i % 3 {
+		case 0:
+			sb.WriteString(id + "\t(σ (γ α) (γ β))\ts b a\n")
+		case 1:
+			sb.WriteString(id + "\t(σ (s (γ b) (γ a)))\tα β\n")
+		case 2:
+			sb.WriteString(id + "\t(σ (γ α)\ts\n")
+		}
+
+		ids = append(ids, id)
+	}
+
+	Config.TrainingDataPath = filepath.Join(dir, "unlabeled.tsv")
+	Config.ConcurrentSampleEvaluations = 8
+
+	if err := os.WriteFile(Config.TrainingDataPath, []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := runScore(t, dir)[1:]
+
+	if len(rows) != len(ids) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(ids))
+	}
+
+	for i, row := range rows {
+		if row[0] != ids[i] {
+			t.Fatalf("row %d has id %s, want %s", i, row[0], ids[i])
+		}
+
+		// every third tree is malformed
+		if ok := row[3] == "ok"; ok != (i%3 != 2) {
+			t.Errorf("sample %s has status %q", row[0], row[3])
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/sync/semaphore"
+	"log"
+	"math"
+	"math/big"
+	"mono-ymk/ykm"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const maxRequestSize = 16 << 20
+
+const readTimeout = 30 * time.Second
+const writeTimeout = 10 * time.Minute
+const idleTimeout = 2 * time.Minute
+
+type ScoreRequest struct {
+	ID         string `json:"id"`
+	Tree       string `json:"tree"`
+	Sentence   string `json:"sentence"`
+	Derivation bool   `json:"derivation"`
+}
+
+type ScoreResponse struct {
+	ID             string          `json:"id,omitempty"`
+	Probability    *big.Float      `json:"probability,omitempty"`
+	LogProbability ykm.JSONFloat   `json:"log_probability"`
+	Score          ykm.JSONFloat   `json:"score"`
+	Derivation     *ykm.Derivation `json:"derivation,omitempty"`
+	Error          string          `json:"error,omitempty"`
+}
+
+type Server struct {
+	scorer *ykm.Scorer
+	sem    *semaphore.Weighted
+}
+
+func NewServer(scorer *ykm.Scorer, concurrency int) *Server {
+	return &Server{
+		scorer: scorer,
+		sem:    semaphore.NewWeighted(int64(concurrency)),
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/health", s.health)
+	mux.HandleFunc("/score", s.score)
+	mux.HandleFunc("/batch", s.batch)
+
+	return mux
+}
+
+func failed(req ScoreRequest, err string) ScoreResponse {
+	return ScoreResponse{
+		ID:             req.ID,
+		LogProbability: ykm.JSONFloat(math.NaN()),
+		Score:          ykm.JSONFloat(math.NaN()),
+		Error:          err,
+	}
+}
+
+// evaluate scores a single request. Callers hold a slot of the semaphore.
+func (s *Server) evaluate(req ScoreRequest) (res ScoreResponse) {
+	res = failed(req, "")
+
+	if req.Tree == "" || req.Sentence == "" {
+		res.Error = "tree and sentence are required"
+
+		return res
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("Panic while scoring sample %s: %v", req.ID, p)
+
+			res.Error = fmt.Sprintf("internal error: %v", p)
+		}
+	}()
+
+	result, err := s.scorer.Score(&ykm.Sample{
+		ID:       req.ID,
+		Tree:     req.Tree,
+		Sentence: req.Sentence,
+	}, req.Derivation)
+
+	if err != nil {
+		res.Error = err.Error()
+
+		return res
+	}
+
+	res.Probability = result.Probability
+	res.LogProbability = ykm.JSONFloat(result.LogProbability)
+	res.Score = ykm.JSONFloat(result.Score)
+	res.Derivation = result.Derivation
+
+	return res
+}
+
+func (s *Server) health(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":    "ok",
+		"iteration": s.scorer.Model().Info().Iteration,
+	})
+}
+
+func (s *Server) score(w http.ResponseWriter, r *http.Request) {
+	var req ScoreRequest
+
+	if err := readJSON(w, r, &req); err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	if err := s.sem.Acquire(r.Context(), 1); err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, failed(req, err.Error()))
+
+		return
+	}
+
+	res := s.evaluate(req)
+
+	s.sem.Release(1)
+
+	if res.Error != "" {
+		writeJSON(w, http.StatusUnprocessableEntity, res)
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) batch(w http.ResponseWriter, r *http.Request) {
+	var reqs []ScoreRequest
+
+	if err := readJSON(w, r, &reqs); err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	res := make([]ScoreResponse, len(reqs))
+
+	var wg sync.WaitGroup
+
+	for i, req := range reqs {
+		if err := s.sem.Acquire(r.Context(), 1); err != nil {
+			for j := i; j < len(reqs); j++ {
+				res[j] = failed(reqs[j], err.Error())
+			}
+
+			break
+		}
+
+		wg.Add(1)
+
+		go func(i int, req ScoreRequest) {
+			defer s.sem.Release(1)
+			defer wg.Done()
+
+			res[i] = s.evaluate(req)
+		}(i, req)
+	}
+
+	wg.Wait()
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+type requestError struct {
+	status int
+	err    error
+}
+
+func (e requestError) Error() string {
+	return e.err.Error()
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	if r.Method != http.MethodPost {
+		return requestError{http.StatusMethodNotAllowed, errors.New("method not allowed")}
+	}
+
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return requestError{http.StatusBadRequest, fmt.Errorf("invalid request: %w", err)}
+	}
+
+	return nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+
+	var re requestError
+
+	if errors.As(err, &re) {
+		status = re.status
+	}
+
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
+
+func Serve() {
+	model := loadModel()
+
+	scorer, err := ykm.NewScorer(model, Config.ScoreNormalization)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	server := NewServer(scorer, Config.ConcurrentSampleEvaluations)
+
+	fmt.Printf("Listening on %s\n", Config.ServeAddress)
+
+	hs := &http.Server{
+		Addr:         Config.ServeAddress,
+		Handler:      server.Handler(),
+		ReadTimeout:  readTimeout,
+		WriteTimeout: writeTimeout,
+		IdleTimeout:  idleTimeout,
+	}
+
+	log.Fatal(hs.ListenAndServe())
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"mono-ymk/ykm"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	scorer, err := ykm.NewScorer(trainMock(t), ykm.NormalizationRaw)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(NewServer(scorer, 2).Handler())
+
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func post(t *testing.T, url, body string, v interface{}) int {
+	t.Helper()
+
+	res, err := http.Post(url, "application/json", strings.NewReader(body))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer res.Body.Close()
+
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		t.Fatalf("%s: invalid response: %v", url, err)
+	}
+
+	return res.StatusCode
+}
+
+func TestServeHealth(t *testing.T) {
+	ts := testServer(t)
+
+	res, err := http.Get(ts.URL + "/health")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer res.Body.Close()
+
+	var health map[string]interface{}
+
+	if err := json.NewDecoder(res.Body).Decode(&health); err != nil {
+		t.Fatal(err)
+	}
+
+	if res.StatusCode != http.StatusOK || health["status"] != "ok" {
+		t.Errorf("got %d %v", res.StatusCode, health)
+	}
+}
+
+func TestServeScore(t *testing.T) {
+	ts := testServer(t)
+
+	var res map[string]interface{}
+
+	status := post(t, ts.URL+"/score", `{"id": "foo", "tree": "(σ (γ α) (γ β))", "sentence": "s b a", "derivation": true}`, &res)
+
+	if status != http.StatusOK || res["error"] != nil {
+		t.Fatalf("got %d %v", status, res)
+	}
+
+	if lp, ok := res["log_probability"].(float64); !ok || lp >= 0 {
+		t.Errorf("log probability = %v, want a negative number", res["log_probability"])
+	}
+
+	derivation, ok := res["derivation"].(map[string]interface{})
+
+	if !ok || derivation["id"] != "foo" || len(derivation["steps"].([]interface{})) == 0 {
+		t.Errorf("unexpected derivation: %v", res["derivation"])
+	}
+}
+
+func TestServeZeroProbability(t *testing.T) {
+	ts := testServer(t)
+
+	// the word zzz is not in the training corpus
+	req := `{"tree": "(σ (γ α) (γ β))", "sentence": "s b zzz", "derivation": true}`
+
+	var res map[string]interface{}
+
+	if status := post(t, ts.URL+"/score", req, &res); status != http.StatusOK {
+		t.Fatalf("got %d %v", status, res)
+	}
+
+	derivation := res["derivation"].(map[string]interface{})
+
+	if res["log_probability"] != nil || derivation["log_probability"] != nil {
+		t.Errorf("log probabilities = %v and %v, want null", res["log_probability"], derivation["log_probability"])
+	}
+
+	var batch []map[string]interface{}
+
+	if status := post(t, ts.URL+"/batch", "["+req+","+req+"]", &batch); status != http.StatusOK || len(batch) != 2 {
+		t.Errorf("got %d %v", status, batch)
+	}
+}
+
+func TestServeBatch(t *testing.T) {
+	ts := testServer(t)
+
+	body := `[
+		{"id": "foo", "tree": "(σ (γ α) (γ β))", "sentence": "s b a"},
+		{"id": "empty", "tree": "", "sentence": "s"},
+		{"id": "bar", "tree": "(σ (s (γ b) (γ a)))", "sentence": "α β"}
+	]`
+
+	var res []map[string]interface{}
+
+	if status := post(t, ts.URL+"/batch", body, &res); status != http.StatusOK {
+		t.Fatalf("got %d %v", status, res)
+	}
+
+	if len(res) != 3 {
+		t.Fatalf("got %d responses, want 3", len(res))
+	}
+
+	for i, id := range []string{"foo", "empty", "bar"} {
+		if res[i]["id"] != id {
+			t.Errorf("response %d has id %v, want %s", i, res[i]["id"], id)
+		}
+	}
+
+	if res[0]["error"] != nil || res[2]["error"] != nil || res[1]["error"] == nil {
+		t.Errorf("only the empty request should fail: %v", res)
+	}
+}
+
+func TestServeErrors(t *testing.T) {
+	ts := testServer(t)
+
+	tests := []struct {
+		path   string
+		body   string
+		status int
+	}{
+		{"/score", `{"tree": "(γ α)"}`, http.StatusUnprocessableEntity},
+		{"/score", `{"tree": "(γ α)", "sentence": "a", "unknown": 1}`, http.StatusBadRequest},
+		{"/score", `{`, http.StatusBadRequest},
+		{"/batch", `{}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		var res map[string]interface{}
+
+		if status := post(t, ts.URL+tt.path, tt.body, &res); status != tt.status || res["error"] == nil {
+			t.Errorf("%s %s: got %d %v, want %d with an error", tt.path, tt.body, status, res, tt.status)
+		}
+	}
+
+	res, err := http.Get(ts.URL + "/score")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /score: got %d, want %d", res.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
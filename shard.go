@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"mono-ymk/ykm"
+	"os"
+	"strconv"
+)
+
+func TrainShard() {
+	tr := ykm.NewTrainer(Config.Options)
+
+	tr.SetOutput(os.Stdout)
+
+	sc, err := tr.TrainShard(Config.ShardIndex, Config.ShardCount)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ykm.WriteShardCounts(Config.CountsOutputPath, sc); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Wrote counts of shard %d for iteration #%d to %s\n", sc.Shard, sc.Iteration, Config.CountsOutputPath)
+}
+
+func Merge() {
+	names, err := ykm.Shards(Config.CountsInputPath)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	shards := make([]*ykm.ShardCounts, 0, len(names))
+
+	for _, name := range names {
+		sc, err := ykm.ReadShardCounts(name)
+
+		if err != nil {
+			log.Fatalf("%s: %v", name, err)
+		}
+
+		shards = append(shards, sc)
+	}
+
+	model, lh, err := ykm.MergeShards(Config.Options, shards)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	printWarnings(model)
+
+	iteration := model.Info().Iteration
+
+	fmt.Printf("Merged %d shards for iteration #%d\n", len(shards), iteration)
+	fmt.Printf("Training log-likelihood: %s\n", lh)
+
+	if Config.ExportModel {
+		if err := ykm.Export(model, strconv.Itoa(iteration)); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if Config.HeldOutDataPath != "" {
+		heldOut, err := ykm.HeldOutLikelihood(Config.HeldOutDataPath, model)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("Held-out log-likelihood: %s\n", heldOut)
+	}
+}
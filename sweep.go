@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"mono-ymk/ykm"
+	"os"
+	"sort"
+)
+
+type ScoredSample struct {
+	Score float64
+	Label bool
+}
+
+type OperatingPoint struct {
+	LogThreshold float64 `json:"log_threshold"`
+	Threshold    string  `json:"threshold"`
+	TP           int     `json:"tp"`
+	FP           int     `json:"fp"`
+	TN           int     `json:"tn"`
+	FN           int     `json:"fn"`
+	Precision    float64 `json:"precision"`
+	Recall       float64 `json:"recall"`
+	FPR          float64 `json:"fpr"`
+	F1           float64 `json:"f1"`
+	Accuracy     float64 `json:"accuracy"`
+}
+
+type SweepReport struct {
+	Normalization    string           `json:"normalization"`
+	Samples          int              `json:"samples"`
+	Positives        int              `json:"positives"`
+	Negatives        int              `json:"negatives"`
+	Fixed            OperatingPoint   `json:"fixed"`
+	ROCAUC           float64          `json:"roc_auc"`
+	AveragePrecision float64          `json:"average_precision"`
+	F1Optimal        OperatingPoint   `json:"f1_optimal"`
+	AccuracyOptimal  OperatingPoint   `json:"accuracy_optimal"`
+	Curve            []OperatingPoint `json:"curve"`
+}
+
+func NewOperatingPoint(threshold float64, tp, fp, tn, fn int) OperatingPoint {
+	ratio := func(a, b int) float64 {
+		if b == 0 {
+			return 0
+		}
+
+		return float64(a) / float64(b)
+	}
+
+	op := OperatingPoint{
+		LogThreshold: threshold,
+		Threshold:    ykm.ExpOf(threshold).Text('e', 6),
+		TP:           tp,
+		FP:           fp,
+		TN:           tn,
+		FN:           fn,
+		Precision:    ratio(tp, tp+fp),
+		Recall:       ratio(tp, tp+fn),
+		FPR:          ratio(fp, fp+tn),
+		Accuracy:     ratio(tp+tn, tp+fp+tn+fn),
+	}
+
+	if op.Precision+op.Recall > 0 {
+		op.F1 = 2 * op.Precision * op.Recall / (op.Precision + op.Recall)
+	}
+
+	if math.IsInf(threshold, 0) {
+		op.LogThreshold = math.Copysign(math.MaxFloat64, threshold)
+	}
+
+	return op
+}
+
+// Predict reports whether a score is classified as a paraphrase. A score at
+// the threshold counts as positive, which matches the operating points of
+// the sweep curve.
+func Predict(score, threshold float64) bool {
+	return score >= threshold
+}
+
+func Classify(samples []ScoredSample, threshold float64) OperatingPoint {
+	tp, fp, tn, fn := 0, 0, 0, 0
+
+	for _, s := range samples {
+		switch {
+		case s.Label && Predict(s.Score, threshold):
+			tp++
+		case !s.Label && Predict(s.Score, threshold):
+			fp++
+		case !s.Label:
+			tn++
+		default:
+			fn++
+		}
+	}
+
+	return NewOperatingPoint(threshold, tp, fp, tn, fn)
+}
+
+func Sweep(samples []ScoredSample, fixed float64) SweepReport {
+	sorted := make([]ScoredSample, len(samples))
+
+	copy(sorted, samples)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Score > sorted[j].Score
+	})
+
+	r := SweepReport{
+		Samples: len(sorted),
+		Fixed:   Classify(sorted, fixed),
+	}
+
+	for _, s := range sorted {
+		if s.Label {
+			r.Positives++
+		} else {
+			r.Negatives++
+		}
+	}
+
+	tp, fp := 0, 0
+
+	start := NewOperatingPoint(math.Inf(1), 0, 0, r.Negatives, r.Positives)
+
+	r.Curve = []OperatingPoint{start}
+	r.F1Optimal = start
+	r.AccuracyOptimal = start
+
+	for i := 0; i < len(sorted); {
+		threshold := sorted[i].Score
+
+		for ; i < len(sorted) && sorted[i].Score == threshold; i++ {
+			if sorted[i].Label {
+				tp++
+			} else {
+				fp++
+			}
+		}
+
+		prev := r.Curve[len(r.Curve)-1]
+		op := NewOperatingPoint(threshold, tp, fp, r.Negatives-fp, r.Positives-tp)
+
+		r.ROCAUC += (op.FPR - prev.FPR) * (op.Recall + prev.Recall) / 2
+		r.AveragePrecision += (op.Recall - prev.Recall) * op.Precision
+
+		if op.F1 > r.F1Optimal.F1 {
+			r.F1Optimal = op
+		}
+
+		if op.Accuracy > r.AccuracyOptimal.Accuracy {
+			r.AccuracyOptimal = op
+		}
+
+		r.Curve = append(r.Curve, op)
+	}
+
+	return r
+}
+
+func (r SweepReport) Write(name string) error {
+	f, err := os.Create(name)
+
+	if err != nil {
+		return fmt.Errorf("error creating file: %w", err)
+	}
+
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("error encoding report: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSweep(t *testing.T) {
+	samples := []ScoredSample{
+		{Score: -0.6, Label: false},
+		{Score: -0.3, Label: true},
+		{Score: -0.1, Label: true},
+		{Score: -0.2, Label: false},
+	}
+
+	r := Sweep(samples, -0.25)
+
+	if r.Samples != 4 || r.Positives != 2 || r.Negatives != 2 || len(r.Curve) != 5 {
+		t.Fatalf("unexpected report: %+v", r)
+	}
+
+	// three of the four positive-negative pairs are ranked correctly
+	if math.Abs(r.ROCAUC-0.75) > 1e-12 {
+		t.Errorf("ROC-AUC = %v, want 0.75", r.ROCAUC)
+	}
+
+	if math.Abs(r.AveragePrecision-5.0/6) > 1e-12 {
+		t.Errorf("AP = %v, want %v", r.AveragePrecision, 5.0/6)
+	}
+
+	if r.F1Optimal.LogThreshold != -0.3 || math.Abs(r.F1Optimal.F1-0.8) > 1e-12 {
+		t.Errorf("F1-optimal point %+v, want threshold -0.3 and F1 0.8", r.F1Optimal)
+	}
+
+	if r.AccuracyOptimal.LogThreshold != -0.1 || r.AccuracyOptimal.Accuracy != 0.75 {
+		t.Errorf("accuracy-optimal point %+v, want threshold -0.1 and accuracy 0.75", r.AccuracyOptimal)
+	}
+
+	if f := r.Fixed; f.TP != 1 || f.FP != 1 || f.TN != 1 || f.FN != 1 {
+		t.Errorf("fixed point %+v, want one of each", f)
+	}
+}
+
+func TestSweepTies(t *testing.T) {
+	r := Sweep([]ScoredSample{{Score: -1, Label: true}, {Score: -1, Label: false}}, -1)
+
+	// tied scores form a single point on the diagonal
+	if len(r.Curve) != 2 || r.ROCAUC != 0.5 || r.AveragePrecision != 0.5 {
+		t.Errorf("unexpected report for tied scores: %+v", r)
+	}
+
+	// a score at the threshold counts as positive
+	if !Predict(-1, -1) || r.Fixed.TP != 1 || r.Fixed.FP != 1 {
+		t.Errorf("fixed point %+v, want both samples predicted positive", r.Fixed)
+	}
+}
+
+func TestSweepEdgeCases(t *testing.T) {
+	tests := map[string][]ScoredSample{
+		"empty":     nil,
+		"positives": {{Score: -1, Label: true}, {Score: math.Inf(-1), Label: true}},
+		"negatives": {{Score: -1, Label: false}},
+	}
+
+	for name, samples := range tests {
+		r := Sweep(samples, 0)
+
+		for _, v := range []float64{r.ROCAUC, r.AveragePrecision, r.F1Optimal.F1, r.AccuracyOptimal.Accuracy} {
+			if math.IsNaN(v) || v < 0 || v > 1 {
+				t.Errorf("%s: report contains %v", name, v)
+			}
+		}
+
+		// infinite thresholds are clamped so that the report is valid JSON
+		path := filepath.Join(t.TempDir(), "report.json")
+
+		if err := r.Write(path); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		data, err := os.ReadFile(path)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded SweepReport
+
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Errorf("%s: invalid report: %v", name, err)
+		}
+	}
+}
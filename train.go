@@ -0,0 +1,17 @@
+package main
+
+import (
+	"log"
+	"mono-ymk/ykm"
+	"os"
+)
+
+func Train() {
+	tr := ykm.NewTrainer(Config.Options)
+
+	tr.SetOutput(os.Stdout)
+
+	if _, err := tr.Train(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/sync/semaphore"
+	"io"
+	"log"
+	"math"
+	"mono-ymk/ykm"
+	"os"
+	"sync"
+)
+
+func Viterbi() {
+	model := loadModel()
+
+	corpus, err := ykm.NewIterator(Config.TrainingDataPath, Config.CorpusFormat)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer corpus.Close()
+
+	f, err := os.Create(Config.ViterbiOutputPath)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+
+	var mu sync.Mutex
+
+	write := func(d ykm.Derivation) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err := enc.Encode(d); err != nil {
+			log.Fatalf("Error writing derivation %s: %v", d.ID, err)
+		}
+	}
+
+	counter := 0
+
+	ctx := context.TODO()
+	sem := semaphore.NewWeighted(int64(Config.ConcurrentSampleEvaluations))
+
+	var wg sync.WaitGroup
+
+	for corpus.Next() && (Config.TrainingSampleLimit == -1 || counter < Config.TrainingSampleLimit) {
+		sample := corpus.Sample()
+
+		mt, e, err := model.InitSample(sample)
+
+		if err != nil {
+			fmt.Printf("Skipped sample %s (%s)\n", sample.ID, err)
+
+			continue
+		}
+
+		if err := sem.Acquire(ctx, 1); err != nil {
+			log.Fatalf("Failed to acquire semaphore: %v", err)
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer sem.Release(1)
+			defer wg.Done()
+
+			g, err := ykm.NewGraph(mt, e, model)
+
+			if err != nil {
+				fmt.Printf("Skipped sample %s (%s)\n", sample.ID, err)
+
+				return
+			}
+
+			steps, score := g.BestDerivation()
+
+			if math.IsInf(score, -1) {
+				fmt.Printf("Skipped sample %s (zero probability)\n", sample.ID)
+
+				return
+			}
+
+			write(ykm.Derivation{
+				ID:             sample.ID,
+				Probability:    ykm.ExpOf(score),
+				LogProbability: ykm.JSONFloat(score),
+				Steps:          steps,
+			})
+
+			fmt.Printf("Derived sample %s [%e]\n", sample.ID, score)
+		}()
+
+		counter++
+	}
+
+	wg.Wait()
+
+	if err := corpus.Error(); err != nil && err != io.EOF {
+		log.Fatalf("Error reading %s: %v", Config.TrainingDataPath, err)
+	}
+}
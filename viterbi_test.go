@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"mono-ymk/ykm"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestViterbi(t *testing.T) {
+	dir := exportMock(t)
+
+	Config.ViterbiOutputPath = filepath.Join(dir, "viterbi.jsonl")
+
+	Viterbi()
+
+	f, err := os.Open(Config.ViterbiOutputPath)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	derived := make(map[string]bool)
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		var d ykm.Derivation
+
+		if err := json.Unmarshal(scanner.Bytes(), &d); err != nil {
+			t.Fatalf("invalid derivation %s: %v", scanner.Text(), err)
+		}
+
+		if len(d.Steps) == 0 || d.LogProbability >= 0 {
+			t.Errorf("unexpected derivation %s", scanner.Text())
+		}
+
+		derived[d.ID] = true
+	}
+
+	if !derived["foo"] || !derived["bar"] || len(derived) != 2 {
+		t.Errorf("got derivations for %v, want foo and bar", derived)
+	}
+}
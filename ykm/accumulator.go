@@ -0,0 +1,171 @@
+package ykm
+
+import (
+	"context"
+	"fmt"
+	"golang.org/x/sync/semaphore"
+	"math/big"
+	"sync"
+)
+
+type sampleResult struct {
+	id     string
+	eval   int
+	counts [5]*Count
+	p      *big.Float
+	tokens int
+	skip   error
+	fail   error
+	watch  *Stopwatch
+}
+
+// accumulator evaluates samples concurrently. Every worker collects the counts
+// of its sample privately and a single reducer merges them in the order the
+// samples were submitted, so the totals do not depend on the number of workers.
+type accumulator struct {
+	batchResult
+
+	counts [5]*Count
+	failed error
+
+	evaluated func(sampleResult)
+
+	ctx     context.Context
+	sem     *semaphore.Weighted
+	queue   chan chan sampleResult
+	pending sync.WaitGroup
+	done    chan struct{}
+}
+
+func newAccumulator(counts [5]*Count, workers int, res batchResult) *accumulator {
+	a := &accumulator{
+		batchResult: res,
+		counts:      counts,
+		ctx:         context.TODO(),
+		sem:         semaphore.NewWeighted(int64(workers)),
+		queue:       make(chan chan sampleResult, 2*workers),
+		done:        make(chan struct{}),
+	}
+
+	go a.reduce()
+
+	return a
+}
+
+func (a *accumulator) reduce() {
+	for slot := range a.queue {
+		r := <-slot
+
+		switch {
+		case r.fail != nil:
+			if a.failed == nil {
+				a.failed = r.fail
+			}
+		case r.skip != nil:
+			a.skipped = append(a.skipped, r.id)
+		default:
+			for j := range a.counts {
+				a.counts[j].Merge(r.counts[j], 1)
+			}
+
+			a.likelihood.Mul(a.likelihood, r.p)
+			a.tokens += r.tokens
+			a.scored++
+		}
+
+		if a.evaluated != nil {
+			a.evaluated(r)
+		}
+
+		a.pending.Done()
+	}
+
+	close(a.done)
+}
+
+func (a *accumulator) push(slot chan sampleResult) {
+	a.pending.Add(1)
+	a.queue <- slot
+}
+
+// skip records a sample that could not be submitted.
+func (a *accumulator) skip(id string, err error) {
+	slot := make(chan sampleResult, 1)
+
+	slot <- sampleResult{id: id, skip: err}
+
+	a.push(slot)
+}
+
+// submit evaluates the graph of a sample on a worker and collects its counts
+// weighted by the sample weight.
+func (a *accumulator) submit(model *Model, sample *Sample, mt *MetaTree, e []string, eval int, draw func(*Graph) error) error {
+	if err := a.sem.Acquire(a.ctx, 1); err != nil {
+		return fmt.Errorf("failed to acquire semaphore: %w", err)
+	}
+
+	slot := make(chan sampleResult, 1)
+
+	a.push(slot)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				panic(fmt.Sprintf("panic while evaluating sample %s: %v", sample.ID, r))
+			}
+
+			a.sem.Release(1)
+		}()
+
+		r := sampleResult{id: sample.ID, eval: eval, watch: NewStopWatch()}
+
+		r.watch.Start()
+
+		g, err := NewGraph(mt, e, model)
+
+		if err != nil {
+			r.skip = err
+			slot <- r
+
+			return
+		}
+
+		if draw != nil {
+			if err := draw(g); err != nil {
+				r.fail = err
+				slot <- r
+
+				return
+			}
+		}
+
+		newCount := NewCount
+
+		if g.logSpace {
+			newCount = NewLogCount
+		}
+
+		r.counts = [5]*Count{newCount(), newCount(), newCount(), newCount(), newCount()}
+		r.p = g.Probability()
+		r.tokens = len(e)
+
+		g.CollectWeightedCounts(sample.Weight, r.counts[0], r.counts[1], r.counts[2], r.counts[3], r.counts[4])
+
+		r.watch.Stop()
+
+		slot <- r
+	}()
+
+	return nil
+}
+
+// wait blocks until every submitted sample has been merged.
+func (a *accumulator) wait() {
+	a.pending.Wait()
+}
+
+func (a *accumulator) close() {
+	close(a.queue)
+
+	<-a.done
+}
@@ -0,0 +1,110 @@
+package ykm
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// equalCounts reports whether two counts hold exactly the same values.
+func equalCounts(a, b *Count) bool {
+	if len(a.Features()) != len(b.Features()) {
+		return false
+	}
+
+	for _, feature := range a.Features() {
+		if len(a.FeatureKeys(feature)) != len(b.FeatureKeys(feature)) {
+			return false
+		}
+
+		for _, key := range a.FeatureKeys(feature) {
+			if a.logSpace && a.GetLog(feature, key) != b.GetLog(feature, key) {
+				return false
+			}
+
+			if !a.logSpace && a.Get(feature, key).Cmp(b.Get(feature, key)) != 0 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func TestAccumulatorOrder(t *testing.T) {
+	for _, logSpace := range []bool{false, true} {
+		f := newFixture(t, func(o *Options) {
+			o.LogSpaceArithmetic = logSpace
+		})
+
+		m := f.train()
+
+		_, samples := f.graphs(m)
+
+		m.training = true
+
+		results := make([]*accumulator, 0)
+		orders := make([]string, 0)
+
+		for _, workers := range []int{1, 8} {
+			acc := newAccumulator(f.counts(), workers, batchResult{likelihood: big.NewFloat(1), skipped: make([]string, 0)})
+
+			order := make([]string, 0)
+
+			acc.evaluated = func(r sampleResult) {
+				order = append(order, r.id)
+			}
+
+			for i := 0; i < 40; i++ {
+				sample := *samples[i%len(samples)]
+
+				sample.ID = fmt.Sprintf("%s-%02d", sample.ID, i)
+
+				if i%5 == 4 {
+					acc.skip(sample.ID, errors.New("skipped"))
+
+					continue
+				}
+
+				mt, e, err := m.InitSample(&sample)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if err := acc.submit(m, &sample, mt, e, i, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			acc.close()
+
+			results = append(results, acc)
+			orders = append(orders, strings.Join(order, ","))
+		}
+
+		sequential, concurrent := results[0], results[1]
+
+		// samples are merged in the order they were submitted
+		if orders[0] != orders[1] {
+			t.Errorf("log space: %t merge order %s with 8 workers, want %s", logSpace, orders[1], orders[0])
+		}
+
+		if concurrent.likelihood.Cmp(sequential.likelihood) != 0 || concurrent.tokens != sequential.tokens || concurrent.scored != 32 {
+			t.Errorf("log space: %t got likelihood %v over %d tokens (scored: %d), want %v over %d tokens", logSpace,
+				concurrent.likelihood, concurrent.tokens, concurrent.scored, sequential.likelihood, sequential.tokens)
+		}
+
+		if strings.Join(concurrent.skipped, ",") != strings.Join(sequential.skipped, ",") || len(concurrent.skipped) != 8 {
+			t.Errorf("log space: %t skipped %v, want %v", logSpace, concurrent.skipped, sequential.skipped)
+		}
+
+		for j := range tables {
+			if !equalCounts(concurrent.counts[j], sequential.counts[j]) {
+				t.Errorf("log space: %t %s counts of 8 workers differ from a single worker", logSpace, tables[j])
+			}
+		}
+	}
+}
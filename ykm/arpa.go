@@ -0,0 +1,136 @@
+package ykm
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const SentenceStart = "<s>"
+const SentenceEnd = "</s>"
+const UnknownWord = "<unk>"
+
+type LanguageModel struct {
+	order   int
+	prob    map[string]float64
+	backoff map[string]float64
+}
+
+func LoadARPA(name string) (*LanguageModel, error) {
+	f, err := os.Open(name)
+
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+
+	defer f.Close()
+
+	lm := &LanguageModel{
+		prob:    make(map[string]float64),
+		backoff: make(map[string]float64),
+	}
+
+	scanner := bufio.NewScanner(f)
+
+	n := 0
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || line == "\\data\\" || strings.HasPrefix(line, "ngram ") {
+			continue
+		}
+
+		if line == "\\end\\" {
+			break
+		}
+
+		if strings.HasPrefix(line, "\\") && strings.HasSuffix(line, "-grams:") {
+			if n, err = strconv.Atoi(line[1 : len(line)-len("-grams:")]); err != nil {
+				return nil, fmt.Errorf("invalid section header: %s", line)
+			}
+
+			if n > lm.order {
+				lm.order = n
+			}
+
+			continue
+		}
+
+		if n == 0 {
+			return nil, errors.New("n-gram entry outside of section")
+		}
+
+		fields := strings.Fields(line)
+
+		if len(fields) != n+1 && len(fields) != n+2 {
+			return nil, fmt.Errorf("invalid %d-gram entry: %s", n, line)
+		}
+
+		p, err := strconv.ParseFloat(fields[0], 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid probability: %w", err)
+		}
+
+		ngram := strings.Join(fields[1:n+1], " ")
+
+		lm.prob[ngram] = p * math.Ln10
+
+		if len(fields) == n+2 {
+			b, err := strconv.ParseFloat(fields[n+1], 64)
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid backoff weight: %w", err)
+			}
+
+			lm.backoff[ngram] = b * math.Ln10
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+
+	if lm.order == 0 {
+		return nil, errors.New("no n-grams found")
+	}
+
+	return lm, nil
+}
+
+func (lm *LanguageModel) conditional(history []string, word string) float64 {
+	if len(history) >= lm.order {
+		history = history[len(history)-lm.order+1:]
+	}
+
+	if p, ok := lm.prob[strings.Join(append(append([]string{}, history...), word), " ")]; ok {
+		return p
+	}
+
+	if len(history) == 0 {
+		if p, ok := lm.prob[UnknownWord]; ok {
+			return p
+		}
+
+		return -99 * math.Ln10
+	}
+
+	return lm.backoff[strings.Join(history, " ")] + lm.conditional(history[1:], word)
+}
+
+func (lm *LanguageModel) Score(tokens []string) float64 {
+	history := []string{SentenceStart}
+	score := 0.0
+
+	for _, token := range append(append([]string{}, tokens...), SentenceEnd) {
+		score += lm.conditional(history, token)
+		history = append(history, token)
+	}
+
+	return score
+}
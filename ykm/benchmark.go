@@ -0,0 +1,147 @@
+package ykm
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+type EStepBenchmark struct {
+	Samples    int
+	Graphs     time.Duration
+	ByKey      time.Duration
+	SinglePass time.Duration
+	Compared   int
+	Mismatches int
+	Difference float64
+}
+
+func (b EStepBenchmark) Speedup() float64 {
+	return b.ByKey.Seconds() / b.SinglePass.Seconds()
+}
+
+func (b EStepBenchmark) String() string {
+	return fmt.Sprintf("samples: %d graphs: %s by key: %s single pass: %s speedup: %.2fx compared: %d mismatches: %d max difference: %e",
+		b.Samples, b.Graphs, b.ByKey, b.SinglePass, b.Speedup(), b.Compared, b.Mismatches, b.Difference)
+}
+
+// BenchmarkEStep collects the expected counts of every training sample with
+// both the per-key reference and the single outside pass and compares the
+// resulting log counts.
+func BenchmarkEStep(m *Model, name string, tolerance float64, out io.Writer) (EStepBenchmark, error) {
+	b := EStepBenchmark{}
+
+	corpus, err := NewIterator(name, m.opts.CorpusFormat)
+
+	if err != nil {
+		return b, err
+	}
+
+	defer corpus.Close()
+
+	newCount := NewCount
+
+	if m.opts.LogSpaceArithmetic {
+		newCount = NewLogCount
+	}
+
+	byKey := [5]*Count{newCount(), newCount(), newCount(), newCount(), newCount()}
+	singlePass := [5]*Count{newCount(), newCount(), newCount(), newCount(), newCount()}
+
+	for corpus.Next() && (m.opts.TrainingSampleLimit == -1 || b.Samples < m.opts.TrainingSampleLimit) {
+		sample := corpus.Sample()
+
+		if !sample.Positive() || sample.Weight == 0 {
+			continue
+		}
+
+		mt, e, err := m.InitSample(sample)
+
+		if err != nil {
+			continue
+		}
+
+		start := time.Now()
+
+		g, err := NewGraph(mt, e, m)
+
+		b.Graphs += time.Since(start)
+
+		if err != nil {
+			continue
+		}
+
+		start = time.Now()
+
+		g.CollectWeightedCountsByKey(sample.Weight, byKey[0], byKey[1], byKey[2], byKey[3], byKey[4])
+
+		b.ByKey += time.Since(start)
+		start = time.Now()
+
+		g.CollectWeightedCounts(sample.Weight, singlePass[0], singlePass[1], singlePass[2], singlePass[3], singlePass[4])
+
+		b.SinglePass += time.Since(start)
+
+		b.Samples++
+	}
+
+	if err := corpus.Error(); err != nil && err != io.EOF {
+		return b, err
+	}
+
+	has := func(c *Count, feature, key string) bool {
+		if _, ok := c.val[feature][key]; ok {
+			return true
+		}
+
+		_, ok := c.log[feature][key]
+
+		return ok
+	}
+
+	for i, table := range []string{"n", "r", "t", "l", "f"} {
+		for _, feature := range byKey[i].Features() {
+			for _, key := range byKey[i].FeatureKeys(feature) {
+				b.Compared++
+
+				if !has(singlePass[i], feature, key) {
+					b.Mismatches++
+
+					fmt.Fprintf(out, "Mismatch count %s [%s : %s] (missing in single pass)\n", table, feature, key)
+
+					continue
+				}
+
+				x := byKey[i].GetLog(feature, key)
+				y := singlePass[i].GetLog(feature, key)
+
+				if math.IsInf(x, -1) && math.IsInf(y, -1) {
+					continue
+				}
+
+				d := math.Abs(x - y)
+
+				b.Difference = math.Max(b.Difference, d)
+
+				if d > tolerance {
+					b.Mismatches++
+
+					fmt.Fprintf(out, "Mismatch count %s [%s : %s] (by key: %e single pass: %e)\n", table, feature, key, x, y)
+				}
+			}
+		}
+
+		for _, feature := range singlePass[i].Features() {
+			for _, key := range singlePass[i].FeatureKeys(feature) {
+				if !has(byKey[i], feature, key) {
+					b.Mismatches++
+
+					fmt.Fprintf(out, "Mismatch count %s [%s : %s] (missing in reference)\n", table, feature, key)
+				}
+			}
+		}
+	}
+
+	return b, nil
+}
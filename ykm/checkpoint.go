@@ -0,0 +1,75 @@
+package ykm
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+type Checkpoint struct {
+	Iteration  int
+	Position   int
+	Evaluated  int
+	Skipped    []string
+	Likelihood *big.Float
+	Tokens     int
+	Scored     int
+	CorpusHash string
+	Model      modelFile
+	Counts     [5]*Count
+}
+
+func WriteCheckpoint(name string, cp *Checkpoint) error {
+	tmp := name + ".tmp"
+
+	file, err := os.Create(tmp)
+
+	if err != nil {
+		return fmt.Errorf("error creating file: %w", err)
+	}
+
+	if err := gob.NewEncoder(file).Encode(cp); err != nil {
+		file.Close()
+
+		return fmt.Errorf("error encoding checkpoint: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error closing file: %w", err)
+	}
+
+	if err := os.Rename(tmp, name); err != nil {
+		return fmt.Errorf("error renaming file: %w", err)
+	}
+
+	return nil
+}
+
+func ReadCheckpoint(name string) (*Checkpoint, error) {
+	file, err := os.Open(name)
+
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+
+	defer file.Close()
+
+	cp := &Checkpoint{}
+
+	if err := gob.NewDecoder(file).Decode(cp); err != nil {
+		return nil, fmt.Errorf("error decoding checkpoint: %w", err)
+	}
+
+	return cp, nil
+}
+
+func (cp *Checkpoint) Restore(opts Options) (*Model, error) {
+	m := NewModel(opts)
+
+	if err := cp.Model.restore(m); err != nil {
+		return nil, fmt.Errorf("error restoring checkpoint: %w", err)
+	}
+
+	return m, nil
+}
@@ -0,0 +1,78 @@
+package ykm
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.gob")
+
+	configure := func(o *Options) {
+		o.EnableReproducibleTraining = true
+		o.TrainingIterationLimit = 1
+		o.CheckpointInterval = 1
+		o.CheckpointPath = path
+	}
+
+	want, _ := Checksum(newFixture(t, configure).train())
+
+	cp, err := ReadCheckpoint(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the last checkpoint is the one written after the last sample
+	if cp.Iteration != 1 || cp.Position != 2 || cp.Evaluated != 2 {
+		t.Errorf("got checkpoint at iteration %d position %d (eval: %d), want 1 2 (eval: 2)", cp.Iteration, cp.Position, cp.Evaluated)
+	}
+
+	f := newFixture(t, func(o *Options) {
+		configure(o)
+
+		o.ResumeCheckpoint = true
+	})
+
+	if got, _ := Checksum(f.train()); got != want {
+		t.Errorf("resumed model has checksum %s, want %s", got, want)
+	}
+}
+
+func TestCheckpointCorpusMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.gob")
+
+	configure := func(o *Options) {
+		o.TrainingIterationLimit = 1
+		o.CheckpointInterval = 1
+		o.CheckpointPath = path
+	}
+
+	newFixture(t, configure).train()
+
+	data, err := os.ReadFile(mockCorpus)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	corpus := writeCorpus(t, "changed.tsv", strings.Replace(string(data), "s b a", "s a b", 1))
+
+	f := newFixture(t, func(o *Options) {
+		configure(o)
+
+		o.TrainingDataPath = corpus
+		o.ResumeCheckpoint = true
+	})
+
+	tr := NewTrainer(f.opts)
+
+	tr.SetOutput(io.Discard)
+
+	if _, err := tr.Train(); err == nil || !strings.Contains(err.Error(), "different training corpus") {
+		t.Errorf("got error %v, want a corpus mismatch", err)
+	}
+}
@@ -0,0 +1,43 @@
+package ykm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Checksum hashes the tables, vocabulary and length statistics of a model in
+// sorted order. Weights are written in exact binary notation, so two models
+// have the same checksum exactly when their parameters are identical,
+// regardless of the file format they were exported to.
+func Checksum(m *Model) (string, error) {
+	h := sha256.New()
+
+	tables := m.Tables()
+
+	for _, name := range []string{"n", "r", "t", "l", "f"} {
+		t := tables[name]
+
+		for _, feature := range sortedKeys(t) {
+			for _, key := range sortedKeys(t[feature]) {
+				if _, err := fmt.Fprintf(h, "%s\t%q\t%q\t%s\n", name, feature, key, t[feature][key].Text('p', 0)); err != nil {
+					return "", err
+				}
+			}
+		}
+	}
+
+	for _, token := range sortedKeys(m.vocabulary) {
+		if _, err := fmt.Fprintf(h, "v\t%q\t%d\n", token, m.vocabulary[token]); err != nil {
+			return "", err
+		}
+	}
+
+	for _, l := range m.lengths {
+		if _, err := fmt.Fprintf(h, "length\t%d\t%d\t%d\n", l.Source, l.Target, l.Count); err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,30 @@
+package ykm
+
+import "testing"
+
+func TestReproducibleTraining(t *testing.T) {
+	for _, logSpace := range []bool{false, true} {
+		checksums := make([]string, 0)
+
+		for _, workers := range []int{1, 8} {
+			f := newFixture(t, func(o *Options) {
+				o.LogSpaceArithmetic = logSpace
+				o.EnableReproducibleTraining = true
+				o.ConcurrentSampleEvaluations = workers
+				o.TrainingIterationLimit = 3
+			})
+
+			sum, err := Checksum(f.train())
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checksums = append(checksums, sum)
+		}
+
+		if checksums[0] != checksums[1] {
+			t.Errorf("log space: %t checksum with 1 worker %s, with 8 workers %s", logSpace, checksums[0], checksums[1])
+		}
+	}
+}
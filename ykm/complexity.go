@@ -1,4 +1,4 @@
-package main
+package ykm
 
 import (
 	"github.com/jonasknobloch/jinn/pkg/tree"
@@ -8,7 +8,7 @@ import (
 
 const intSize = 32 << (^int(0) >> 32 & 1)
 
-func O(t *tree.Tree, l int) (int, bool) {
+func O(t *tree.Tree, l int, opts Options) (int, bool) {
 	numSubstrings := func(l, k int) int {
 		if k == 0 {
 			return 1
@@ -30,7 +30,7 @@ func O(t *tree.Tree, l int) (int, bool) {
 			if len(st.Children) == 0 {
 				numInsertions := 1
 
-				if Config.EnableTerminalInsertions {
+				if opts.EnableTerminalInsertions {
 					numInsertions = 3
 				}
 
@@ -43,13 +43,13 @@ func O(t *tree.Tree, l int) (int, bool) {
 			if len(st.Children) > 0 {
 				numInsertions := 1
 
-				if Config.EnableInteriorInsertions {
+				if opts.EnableInteriorInsertions {
 					numInsertions = 3
 				}
 
 				numTranslations := 0
 
-				if Config.EnablePhrasalTranslations {
+				if opts.EnablePhrasalTranslations {
 					numTranslations = numInsertions * 1
 				}
 
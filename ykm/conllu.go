@@ -0,0 +1,268 @@
+package ykm
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/jonasknobloch/jinn/pkg/tree"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const FormatPTB = "ptb"
+const FormatCoNLLU = "conllu"
+
+const HeadRelation = "head"
+
+type DependencyToken struct {
+	ID     int
+	Form   string
+	UPOS   string
+	Head   int
+	DepRel string
+}
+
+func DecodeTree(s, format string) (*tree.Tree, error) {
+	if format == FormatAuto {
+		format = FormatPTB
+
+		if !strings.HasPrefix(strings.TrimSpace(s), "(") {
+			format = FormatCoNLLU
+		}
+	}
+
+	switch format {
+	case FormatPTB:
+		return tree.NewDecoder().Decode(s)
+	case FormatCoNLLU:
+		tokens, err := ParseCoNLLU(s)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return DependencyTree(tokens)
+	default:
+		return nil, fmt.Errorf("unknown tree format: %s", format)
+	}
+}
+
+func ParseCoNLLU(s string) ([]DependencyToken, error) {
+	tokens := make([]DependencyToken, 0)
+
+	for n, line := range strings.Split(s, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+
+		if len(fields) != 10 {
+			return nil, fmt.Errorf("line %d: expected 10 columns but got %d", n+1, len(fields))
+		}
+
+		if strings.ContainsAny(fields[0], "-.") {
+			continue // multiword tokens and empty nodes
+		}
+
+		id, err := strconv.Atoi(fields[0])
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid id: %q", n+1, fields[0])
+		}
+
+		head, err := strconv.Atoi(fields[6])
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid head: %q", n+1, fields[6])
+		}
+
+		tokens = append(tokens, DependencyToken{
+			ID:     id,
+			Form:   strings.ReplaceAll(fields[1], " ", "_"),
+			UPOS:   fields[3],
+			Head:   head,
+			DepRel: fields[7],
+		})
+	}
+
+	if len(tokens) == 0 {
+		return nil, errors.New("empty dependency tree")
+	}
+
+	return tokens, nil
+}
+
+// DependencyTree converts tokens into a tree whose interior nodes are labeled
+// with the relation and POS tag of a head word; the head word itself becomes
+// a child labeled head/POS next to the subtrees of its dependents.
+func DependencyTree(tokens []DependencyToken) (*tree.Tree, error) {
+	byID := make(map[int]DependencyToken, len(tokens))
+	dependents := make(map[int][]int, len(tokens))
+
+	for _, t := range tokens {
+		byID[t.ID] = t
+	}
+
+	for _, t := range tokens {
+		if _, ok := byID[t.Head]; !ok && t.Head != 0 {
+			return nil, fmt.Errorf("token %d has unknown head %d", t.ID, t.Head)
+		}
+
+		dependents[t.Head] = append(dependents[t.Head], t.ID)
+	}
+
+	if len(dependents[0]) != 1 {
+		return nil, fmt.Errorf("expected a single root but got %d", len(dependents[0]))
+	}
+
+	visited := make(map[int]bool, len(tokens))
+
+	var build func(id int) (*tree.Tree, error)
+	build = func(id int) (*tree.Tree, error) {
+		if visited[id] {
+			return nil, fmt.Errorf("cycle at token %d", id)
+		}
+
+		visited[id] = true
+
+		t := byID[id]
+
+		word := &tree.Tree{Label: t.Form}
+
+		if len(dependents[id]) == 0 {
+			return &tree.Tree{Label: t.DepRel + "/" + t.UPOS, Children: []*tree.Tree{word}}, nil
+		}
+
+		ids := append([]int{id}, dependents[id]...)
+
+		sort.Ints(ids)
+
+		children := make([]*tree.Tree, 0, len(ids))
+
+		for _, c := range ids {
+			if c == id {
+				children = append(children, &tree.Tree{Label: HeadRelation + "/" + t.UPOS, Children: []*tree.Tree{word}})
+
+				continue
+			}
+
+			st, err := build(c)
+
+			if err != nil {
+				return nil, err
+			}
+
+			children = append(children, st)
+		}
+
+		return &tree.Tree{Label: t.DepRel + "/" + t.UPOS, Children: children}, nil
+	}
+
+	root, err := build(dependents[0][0])
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(visited) != len(tokens) {
+		return nil, errors.New("dependency graph is not connected")
+	}
+
+	return root, nil
+}
+
+func conlluReader(r io.Reader) func() (*Sample, error) {
+	scanner := bufio.NewScanner(r)
+
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	line := 0
+
+	return func() (*Sample, error) {
+		var sb strings.Builder
+
+		comments := make(map[string]string)
+		start := 0
+
+		for scanner.Scan() {
+			line++
+
+			text := strings.TrimRight(scanner.Text(), "\r")
+
+			if text == "" {
+				if sb.Len() == 0 {
+					continue
+				}
+
+				break
+			}
+
+			if start == 0 {
+				start = line
+			}
+
+			if strings.HasPrefix(text, "#") {
+				if kv := strings.SplitN(strings.TrimSpace(text[1:]), "=", 2); len(kv) == 2 {
+					comments[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+				}
+
+				continue
+			}
+
+			sb.WriteString(text)
+			sb.WriteString("\n")
+		}
+
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+
+		if sb.Len() == 0 {
+			return nil, io.EOF
+		}
+
+		if comments["target"] == "" {
+			return nil, fmt.Errorf("line %d: missing target comment", start)
+		}
+
+		if label, ok := comments["label"]; ok && label == "" {
+			return nil, fmt.Errorf("line %d: empty label comment", start)
+		}
+
+		sample, err := NewSample(comments["sent_id"], sb.String(), comments["target"], comments["label"])
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", start, err)
+		}
+
+		if w, ok := comments["weight"]; ok {
+			weight, err := parseWeight(w)
+
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", start, err)
+			}
+
+			sample.Weight = weight
+		}
+
+		for key, val := range comments {
+			switch key {
+			case "sent_id", "target", "label", "weight":
+				continue
+			}
+
+			if sample.Meta == nil {
+				sample.Meta = make(map[string]interface{})
+			}
+
+			sample.Meta[key] = val
+		}
+
+		return sample, nil
+	}
+}
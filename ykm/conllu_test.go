@@ -0,0 +1,89 @@
+package ykm
+
+import (
+	"github.com/jonasknobloch/jinn/pkg/tree"
+	"strings"
+	"testing"
+)
+
+const conlluSentence = "1-2\tThe cat\t_\t_\t_\t_\t_\t_\t_\t_\n" +
+	"1\tThe\tthe\tDET\t_\t_\t2\tdet\t_\t_\n" +
+	"2\tcat\tcat\tNOUN\t_\t_\t3\tnsubj\t_\t_\n" +
+	"3\tsleeps\tsleep\tVERB\t_\t_\t0\troot\t_\t_\n"
+
+func TestDependencyTree(t *testing.T) {
+	got, err := DecodeTree(conlluSentence, FormatAuto)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := tree.NewDecoder().Decode("(root/VERB (nsubj/NOUN (det/DET The) (head/NOUN cat)) (head/VERB sleeps))")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !got.Equals(want) {
+		t.Errorf("got tree %v, want %v", got, want)
+	}
+
+	row := func(id, head string) string {
+		return id + "\tx\tx\tX\t_\t_\t" + head + "\tdep\t_\t_\n"
+	}
+
+	tests := map[string]string{
+		"columns":   "1\tx\tx\n",
+		"id":        row("a", "0"),
+		"head":      row("1", "a"),
+		"roots":     row("1", "0") + row("2", "0"),
+		"unknown":   row("1", "0") + row("2", "5"),
+		"connected": row("1", "0") + row("2", "3") + row("3", "2"),
+		"empty":     "# comment\n",
+	}
+
+	for name, s := range tests {
+		if _, err := DecodeTree(s, FormatCoNLLU); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestReadCoNLLU(t *testing.T) {
+	corpus := "# sent_id = a\n# target = the cat sleeps\n# label = 1\n# weight = 2\n# source = ud\n" + conlluSentence +
+		"\n\n# sent_id = b\n# target = it sleeps\n" + conlluSample
+
+	samples, err := readCorpus(t, writeCorpus(t, "corpus.conllu", corpus), FormatAuto)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(samples) != 2 || samples[0].ID != "a" || samples[1].ID != "b" {
+		t.Fatalf("got samples %s", sampleIDs(samples))
+	}
+
+	if s := samples[0]; !s.Positive() || s.Weight != 2 || s.Meta["source"] != "ud" || s.Sentence != "the cat sleeps" {
+		t.Errorf("unexpected sample %+v", s)
+	}
+
+	if _, err := readCorpus(t, writeCorpus(t, "missing.conllu", "# sent_id = a\n"+conlluSentence), FormatAuto); err == nil || !strings.Contains(err.Error(), "line 1: missing target comment") {
+		t.Errorf("got error %v, want a missing target", err)
+	}
+}
+
+func TestTrainCoNLLU(t *testing.T) {
+	corpus := writeCorpus(t, "corpus.conllu", "# sent_id = a\n# target = a cat sleeps\n# label = 1\n"+conlluSentence)
+
+	f := newFixture(t, func(o *Options) {
+		o.TrainingDataPath = corpus
+		o.TrainingIterationLimit = 2
+	})
+
+	m := f.train()
+
+	// the words of the dependency tree are translated into the target
+	if p := m.t["The"]["a"]; p == nil || p.Sign() <= 0 {
+		t.Errorf("no translation of The into a in table %v", m.t)
+	}
+}
@@ -0,0 +1,349 @@
+package ykm
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const StdinPath = "-"
+
+const FormatAuto = "auto"
+const FormatJSONL = "jsonl"
+
+type Iterator struct {
+	shards []string
+	shard  int
+	format string
+	file   *os.File
+	gz     *gzip.Reader
+	read   func() (*Sample, error)
+	error  error
+	sample *Sample
+}
+
+func Shards(name string) ([]string, error) {
+	if name == StdinPath {
+		return []string{StdinPath}, nil
+	}
+
+	shards := make([]string, 0)
+
+	for _, pattern := range strings.Split(name, ",") {
+		pattern = strings.TrimSpace(pattern)
+
+		if pattern == "" {
+			continue
+		}
+
+		matches, err := filepath.Glob(pattern)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid corpus pattern %s: %w", pattern, err)
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no corpus files match %s", pattern)
+		}
+
+		shards = append(shards, matches...)
+	}
+
+	if len(shards) == 0 {
+		return nil, errors.New("empty corpus path")
+	}
+
+	return shards, nil
+}
+
+func NewIterator(name, format string) (*Iterator, error) {
+	if format != FormatAuto && format != FormatTSV && format != FormatJSONL && format != FormatCoNLLU {
+		return nil, fmt.Errorf("unknown corpus format: %s", format)
+	}
+
+	shards, err := Shards(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	i := &Iterator{
+		shards: shards,
+		format: format,
+	}
+
+	if err := i.open(0); err != nil {
+		i.Close()
+
+		return nil, err
+	}
+
+	return i, nil
+}
+
+func (i *Iterator) open(shard int) error {
+	name := i.shards[shard]
+
+	var r io.Reader = os.Stdin
+
+	if name != StdinPath {
+		f, err := os.Open(name)
+
+		if err != nil {
+			return err
+		}
+
+		i.file = f
+		r = f
+	}
+
+	br := bufio.NewReader(r)
+
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		i.gz = gz
+		br = bufio.NewReader(gz)
+	}
+
+	var read func() (*Sample, error)
+	var err error
+
+	switch corpusFormat(name, i.format, br) {
+	case FormatJSONL:
+		read = jsonlReader(br)
+	case FormatCoNLLU:
+		read = conlluReader(br)
+	default:
+		read, err = tsvReader(br)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	i.shard = shard
+	i.read = read
+
+	return nil
+}
+
+func corpusFormat(name, format string, br *bufio.Reader) string {
+	if format != FormatAuto {
+		return format
+	}
+
+	switch filepath.Ext(strings.TrimSuffix(name, ".gz")) {
+	case "." + FormatJSONL, "." + FormatJSON:
+		return FormatJSONL
+	case "." + FormatTSV:
+		return FormatTSV
+	case "." + FormatCoNLLU:
+		return FormatCoNLLU
+	}
+
+	if b, err := br.Peek(1); err == nil {
+		switch b[0] {
+		case '{':
+			return FormatJSONL
+		case '#':
+			return FormatCoNLLU
+		}
+	}
+
+	return FormatTSV
+}
+
+func tsvReader(r io.Reader) (func() (*Sample, error), error) {
+	cr := csv.NewReader(r)
+
+	cr.Comma = '\t'
+
+	header, err := cr.Read()
+
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+
+	for i, name := range header {
+		columns[name] = i
+	}
+
+	for _, column := range []string{"ID", "Tree", "Sentence"} {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("missing %s column in header row", column)
+		}
+	}
+
+	return func() (*Sample, error) {
+		record, err := cr.Read()
+
+		if err != nil {
+			return nil, err
+		}
+
+		label := ""
+
+		if c, ok := columns["Label"]; ok {
+			if label = record[c]; label == "" {
+				return nil, fmt.Errorf("sample %s: empty label", record[columns["ID"]])
+			}
+		}
+
+		sample, err := NewSample(record[columns["ID"]], record[columns["Tree"]], record[columns["Sentence"]], label)
+
+		if err != nil {
+			return nil, fmt.Errorf("sample %s: %w", record[columns["ID"]], err)
+		}
+
+		for name, c := range columns {
+			switch name {
+			case "ID", "Tree", "Sentence", "Label":
+				continue
+			case "Weight":
+				if sample.Weight, err = parseWeight(record[c]); err != nil {
+					return nil, fmt.Errorf("sample %s: %w", sample.ID, err)
+				}
+			default:
+				if sample.Meta == nil {
+					sample.Meta = make(map[string]interface{})
+				}
+
+				sample.Meta[name] = record[c]
+			}
+		}
+
+		return sample, nil
+	}, nil
+}
+
+func (i *Iterator) Next() bool {
+	sample, err := i.read()
+
+	for err == io.EOF && i.shard+1 < len(i.shards) {
+		if err := i.Close(); err != nil {
+			i.error = err
+
+			return false
+		}
+
+		if err := i.open(i.shard + 1); err != nil {
+			i.error = err
+
+			return false
+		}
+
+		sample, err = i.read()
+	}
+
+	if err != nil {
+		i.error = err
+
+		return false
+	}
+
+	i.sample = sample
+
+	return true
+}
+
+func (i *Iterator) Reset() error {
+	if i.shards[0] == StdinPath {
+		return errors.New("cannot rewind stdin")
+	}
+
+	if err := i.Close(); err != nil {
+		return err
+	}
+
+	i.error = nil
+	i.sample = nil
+
+	return i.open(0)
+}
+
+func (i *Iterator) Close() error {
+	var err error
+
+	if i.gz != nil {
+		err = i.gz.Close()
+
+		i.gz = nil
+	}
+
+	if i.file == nil {
+		return err
+	}
+
+	if e := i.file.Close(); err == nil {
+		err = e
+	}
+
+	i.file = nil
+
+	return err
+}
+
+func (i *Iterator) Error() error {
+	return i.error
+}
+
+func (i *Iterator) Sample() *Sample {
+	if i.sample == nil {
+		panic("Sample called before Next")
+	}
+
+	return i.sample
+}
+
+func hashCorpus(name string) (string, error) {
+	shards, err := Shards(name)
+
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+
+	for _, shard := range shards {
+		if shard == StdinPath {
+			return "", errors.New("cannot hash stdin")
+		}
+
+		if err := hashFile(h, shard); err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(w io.Writer, name string) error {
+	f, err := os.Open(name)
+
+	if err != nil {
+		return fmt.Errorf("error opening file: %w", err)
+	}
+
+	defer f.Close()
+
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("error hashing file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package ykm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func gzipCorpus(t *testing.T, content string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+
+	if _, err := gz.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func sampleIDs(samples []*Sample) string {
+	ids := make([]string, 0, len(samples))
+
+	for _, s := range samples {
+		ids = append(ids, s.ID)
+	}
+
+	return strings.Join(ids, ",")
+}
+
+func TestIteratorShards(t *testing.T) {
+	data, err := os.ReadFile(mockCorpus)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "a.tsv"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "b.tsv.gz"), gzipCorpus(t, string(data)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		filepath.Join(dir, "a.tsv"):                                        "foo,bar",
+		filepath.Join(dir, "b.tsv.gz"):                                     "foo,bar",
+		filepath.Join(dir, "*"):                                            "foo,bar,foo,bar",
+		filepath.Join(dir, "b.tsv.gz") + "," + filepath.Join(dir, "a.tsv"): "foo,bar,foo,bar",
+	}
+
+	for name, want := range tests {
+		samples, err := readCorpus(t, name, FormatAuto)
+
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+
+			continue
+		}
+
+		if got := sampleIDs(samples); got != want {
+			t.Errorf("%s: got samples %s, want %s", name, got, want)
+		}
+	}
+
+	if _, err := NewIterator(filepath.Join(dir, "*.jsonl"), FormatAuto); err == nil {
+		t.Error("expected an error for a pattern without matches")
+	}
+}
+
+func TestIteratorReset(t *testing.T) {
+	data, err := os.ReadFile(mockCorpus)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	it, err := NewIterator(writeCorpus(t, "mock.tsv.gz", string(gzipCorpus(t, string(data)))), FormatAuto)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer it.Close()
+
+	for pass := 0; pass < 2; pass++ {
+		ids := make([]string, 0)
+
+		for it.Next() {
+			ids = append(ids, it.Sample().ID)
+		}
+
+		if got := strings.Join(ids, ","); got != "foo,bar" {
+			t.Errorf("pass %d: got samples %s", pass, got)
+		}
+
+		if err := it.Reset(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCorpusStatisticsErrors(t *testing.T) {
+	data, err := os.ReadFile(mockCorpus)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// repeat the samples so that the truncation falls behind the header
+	content := string(data) + strings.Repeat(strings.SplitN(string(data), "\n", 2)[1], 200)
+
+	gz := gzipCorpus(t, content)
+
+	corpora := map[string]string{
+		"truncated.tsv.gz": string(gz[:len(gz)/2]),
+		"invalid.jsonl":    `{"tree": "(γ α)", "sentence": "a", "label": 1}` + "\n{\n",
+	}
+
+	for name, content := range corpora {
+		path := writeCorpus(t, name, content)
+
+		if _, err := CountTokenOccurrences(path, FormatAuto, -1); err == nil {
+			t.Errorf("%s: expected an error counting tokens", name)
+		}
+
+		f := newFixture(t, func(o *Options) {
+			o.EnablePhrasalTranslations = true
+			o.PhraseLengthLimit = 2
+		})
+
+		if _, err := CountPhrasalPairs(path, NewModel(f.opts), -1); err == nil {
+			t.Errorf("%s: expected an error counting phrasal pairs", name)
+		}
+
+		if _, err := CountLengths(path, NewModel(f.opts), -1); err == nil {
+			t.Errorf("%s: expected an error counting lengths", name)
+		}
+	}
+}
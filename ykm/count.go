@@ -0,0 +1,311 @@
+package ykm
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+)
+
+type Count struct {
+	val map[string]map[string]*big.Float
+	log map[string]map[string]float64
+	rwm sync.RWMutex
+
+	logSpace bool
+	sorted   bool
+}
+
+func NewCount() *Count {
+	return &Count{
+		val: make(map[string]map[string]*big.Float),
+		log: make(map[string]map[string]float64),
+		rwm: sync.RWMutex{},
+	}
+}
+
+func NewLogCount() *Count {
+	c := NewCount()
+
+	c.logSpace = true
+
+	return c
+}
+
+// newCounter returns a constructor for training counts. With reproducible
+// training enabled, the counts iterate over features and keys in sorted order.
+func newCounter(opts Options) func() *Count {
+	return func() *Count {
+		c := NewCount()
+
+		c.logSpace = opts.LogSpaceArithmetic
+		c.sorted = opts.EnableReproducibleTraining
+
+		return c
+	}
+}
+
+func (c *Count) Add(feature, key string, value *big.Float) {
+	c.rwm.Lock()
+	defer c.rwm.Unlock()
+
+	if _, ok := c.val[feature]; !ok {
+		c.val[feature] = make(map[string]*big.Float)
+	}
+
+	if _, ok := c.val[feature][key]; !ok {
+		c.val[feature][key] = new(big.Float)
+	}
+
+	c.val[feature][key].Add(c.val[feature][key], value)
+}
+
+func (c *Count) AddLog(feature, key string, value float64) {
+	c.rwm.Lock()
+	defer c.rwm.Unlock()
+
+	if _, ok := c.log[feature]; !ok {
+		c.log[feature] = make(map[string]float64)
+	}
+
+	if _, ok := c.log[feature][key]; !ok {
+		c.log[feature][key] = logZero
+	}
+
+	c.log[feature][key] = logAdd(c.log[feature][key], value)
+}
+
+func (c *Count) Get(feature, key string) *big.Float {
+	if c.logSpace {
+		return ExpOf(c.log[feature][key])
+	}
+
+	return c.val[feature][key]
+}
+
+func (c *Count) GetLog(feature, key string) float64 {
+	if c.logSpace {
+		return c.log[feature][key]
+	}
+
+	return LogOf(c.val[feature][key])
+}
+
+func (c *Count) ForEach(p map[string]map[string][]*Node, f func(string, string) (*big.Float, bool)) {
+	for feature, keys := range p {
+		for key := range keys {
+			val, ok := f(feature, key)
+
+			if !ok {
+				continue
+			}
+
+			c.Add(feature, key, val)
+		}
+	}
+}
+
+func (c *Count) ForEachLog(p map[string]map[string][]*Node, f func(string, string) (float64, bool)) {
+	for feature, keys := range p {
+		for key := range keys {
+			val, ok := f(feature, key)
+
+			if !ok {
+				continue
+			}
+
+			c.AddLog(feature, key, val)
+		}
+	}
+}
+
+func (c *Count) Sum(feature string) *big.Float {
+	if c.logSpace {
+		return ExpOf(c.LogSum(feature))
+	}
+
+	sum := new(big.Float)
+
+	if c.sorted {
+		for _, key := range c.FeatureKeys(feature) {
+			sum.Add(sum, c.val[feature][key])
+		}
+
+		return sum
+	}
+
+	for _, value := range c.val[feature] {
+		sum.Add(sum, value)
+	}
+
+	return sum
+}
+
+func (c *Count) LogSum(feature string) float64 {
+	if !c.logSpace {
+		return LogOf(c.Sum(feature))
+	}
+
+	sum := logZero
+
+	if c.sorted {
+		for _, key := range c.FeatureKeys(feature) {
+			sum = logAdd(sum, c.log[feature][key])
+		}
+
+		return sum
+	}
+
+	for _, value := range c.log[feature] {
+		sum = logAdd(sum, value)
+	}
+
+	return sum
+}
+
+func (c *Count) Features() []string {
+	features := make([]string, 0, len(c.val)+len(c.log))
+
+	for feature := range c.val {
+		features = append(features, feature)
+	}
+
+	for feature := range c.log {
+		features = append(features, feature)
+	}
+
+	if c.sorted {
+		sort.Strings(features)
+	}
+
+	return features
+}
+
+func (c *Count) FeatureKeys(feature string) []string {
+	keys := make([]string, 0, c.Size(feature))
+
+	for key := range c.val[feature] {
+		keys = append(keys, key)
+	}
+
+	for key := range c.log[feature] {
+		keys = append(keys, key)
+	}
+
+	if c.sorted {
+		sort.Strings(keys)
+	}
+
+	return keys
+}
+
+func (c *Count) Keys() map[string]bool {
+	keys := make(map[string]bool)
+
+	for _, feature := range c.Features() {
+		for _, key := range c.FeatureKeys(feature) {
+			keys[key] = true
+		}
+	}
+
+	return keys
+}
+
+func (c *Count) Size(feature string) int {
+	return len(c.val[feature]) + len(c.log[feature])
+}
+
+func (c *Count) Scale(w float64) {
+	c.rwm.Lock()
+	defer c.rwm.Unlock()
+
+	bw := big.NewFloat(w)
+	lw := math.Log(w)
+
+	for _, keys := range c.val {
+		for _, val := range keys {
+			val.Mul(val, bw)
+		}
+	}
+
+	for _, keys := range c.log {
+		for key, val := range keys {
+			keys[key] = val + lw
+		}
+	}
+}
+
+func (c *Count) Merge(o *Count, w float64) {
+	bw := big.NewFloat(w)
+	lw := math.Log(w)
+
+	for feature, keys := range o.val {
+		for key, val := range keys {
+			c.Add(feature, key, new(big.Float).Mul(val, bw))
+		}
+	}
+
+	for feature, keys := range o.log {
+		for key, val := range keys {
+			c.AddLog(feature, key, val+lw)
+		}
+	}
+}
+
+func (c *Count) Reset() {
+	for feature, keys := range c.val {
+		for key := range keys {
+			c.val[feature][key].SetFloat64(0)
+		}
+	}
+
+	for feature, keys := range c.log {
+		for key := range keys {
+			c.log[feature][key] = logZero
+		}
+	}
+}
+
+type countSnapshot struct {
+	Val      map[string]map[string]*big.Float
+	Log      map[string]map[string]float64
+	LogSpace bool
+}
+
+func (c *Count) GobEncode() ([]byte, error) {
+	c.rwm.RLock()
+	defer c.rwm.RUnlock()
+
+	var buf bytes.Buffer
+
+	if err := gob.NewEncoder(&buf).Encode(countSnapshot{c.val, c.log, c.logSpace}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (c *Count) GobDecode(data []byte) error {
+	var s countSnapshot
+
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&s); err != nil {
+		return err
+	}
+
+	c.rwm.Lock()
+	defer c.rwm.Unlock()
+
+	c.val, c.log, c.logSpace = s.Val, s.Log, s.LogSpace
+
+	if c.val == nil {
+		c.val = make(map[string]map[string]*big.Float)
+	}
+
+	if c.log == nil {
+		c.log = make(map[string]map[string]float64)
+	}
+
+	return nil
+}
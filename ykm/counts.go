@@ -0,0 +1,806 @@
+package ykm
+
+import (
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// InsideWeight sums the weight of the hyperedges below n that match the
+// operation keys of the filter. Without count correction, a reordering or
+// translation filter also keeps all hyperedges of the other kind.
+func (g *Graph) InsideWeight(n *Node, filter [3]string, lambda, kappa *big.Float) *big.Float {
+	correct := g.opts.EnableCountCorrection
+
+	sumI := new(big.Float)
+
+	for _, i := range g.succ[n] {
+		if !i.valid {
+			continue
+		}
+
+		if filter[0] != "" && i.n.Key() != filter[0] {
+			continue
+		}
+
+		sumT := new(big.Float)
+		sumR := new(big.Float)
+
+		for _, rt := range g.succ[i] {
+			if !rt.valid {
+				continue
+			}
+
+			if rt.nType == SubNode {
+				if correct && filter[2] != "" || filter[1] != "" && rt.r.Key() != filter[1] {
+					continue
+				}
+
+				sumP := new(big.Float)
+
+				for _, p := range g.succ[rt] {
+					if !p.valid {
+						continue
+					}
+
+					prod := big.NewFloat(1)
+
+					for _, m := range g.succ[p] {
+						if !m.valid {
+							continue
+						}
+
+						prod.Mul(prod, g.Beta(m))
+					}
+
+					sumP.Add(sumP, prod)
+				}
+
+				sumR.Add(sumR, sumP.Mul(sumP, g.edges[[2]*Node{i, rt}]))
+			}
+
+			if rt.nType == FinalNode {
+				if correct && filter[1] != "" || filter[2] != "" && rt.t.Key() != filter[2] {
+					continue
+				}
+
+				sumT.Add(sumT, g.edges[[2]*Node{i, rt}])
+			}
+		}
+
+		if n.lambda != nil && n.kappa != nil {
+			if lambda == nil {
+				lambda = n.lambda
+			}
+
+			if kappa == nil {
+				kappa = n.kappa
+			}
+
+			sumT.Mul(sumT, lambda)
+			sumR.Mul(sumR, kappa)
+		}
+
+		sumI.Add(sumI, sumT.Mul(sumT, g.edges[[2]*Node{n, i}]))
+		sumI.Add(sumI, sumR.Mul(sumR, g.edges[[2]*Node{n, i}]))
+	}
+
+	return sumI
+}
+
+func (g *Graph) InsertionCount(feature, key string) (*big.Float, bool) {
+	sum := new(big.Float)
+
+	var ms []*Node
+	var ok bool
+
+	if ms, ok = g.insertions[feature][key]; !ok {
+		return sum, ok
+	}
+
+	valid := false
+
+	for _, m := range ms {
+		if !m.valid {
+			continue
+		}
+
+		valid = true
+
+		prod := big.NewFloat(1)
+
+		prod.Mul(prod, g.pAlpha[m])
+		prod.Mul(prod, g.InsideWeight(m, [3]string{key}, nil, nil))
+
+		prod.Quo(prod, g.Beta(g.nodes[0]))
+
+		sum.Add(sum, prod)
+	}
+
+	return sum, valid
+}
+
+func (g *Graph) ReorderingCount(feature, key string) (*big.Float, bool) {
+	sum := new(big.Float)
+
+	var ms []*Node
+	var ok bool
+
+	if ms, ok = g.reorderings[feature][key]; !ok {
+		return sum, ok
+	}
+
+	valid := false
+
+	for _, m := range ms {
+		if !m.valid {
+			continue
+		}
+
+		valid = true
+
+		prod := big.NewFloat(1)
+
+		prod.Mul(prod, g.pAlpha[m])
+		prod.Mul(prod, g.InsideWeight(m, [3]string{"", key}, nil, nil))
+
+		prod.Quo(prod, g.Beta(g.nodes[0]))
+
+		sum.Add(sum, prod)
+	}
+
+	return sum, valid
+}
+
+func (g *Graph) TranslationCount(feature, key string) (*big.Float, bool) {
+	sum := new(big.Float)
+
+	var ms []*Node
+	var ok bool
+
+	if ms, ok = g.translations[feature][key]; !ok {
+		return sum, ok
+	}
+
+	valid := false
+
+	for _, m := range ms {
+		if !m.valid {
+			continue
+		}
+
+		valid = true
+
+		prod := big.NewFloat(1)
+
+		prod.Mul(prod, g.pAlpha[m])
+		prod.Mul(prod, g.InsideWeight(m, [3]string{"", "", key}, nil, nil))
+
+		prod.Quo(prod, g.Beta(g.nodes[0]))
+
+		sum.Add(sum, prod)
+	}
+
+	return sum, valid
+}
+
+func (g *Graph) LambdaCount(feature, key string) (*big.Float, bool) {
+	sum := new(big.Float)
+
+	var ms []*Node
+	var ok bool
+
+	if ms, ok = g.lambda[feature][key]; !ok {
+		return sum, ok
+	}
+
+	valid := false
+
+	for _, m := range ms {
+		if !m.valid {
+			continue
+		}
+
+		valid = true
+
+		prod := big.NewFloat(1)
+
+		prod.Mul(prod, g.pAlpha[m])
+
+		switch key {
+		case LambdaKey:
+			prod.Mul(prod, g.InsideWeight(m, [3]string{}, nil, new(big.Float)))
+		case KappaKey:
+			prod.Mul(prod, g.InsideWeight(m, [3]string{}, new(big.Float), nil))
+		default:
+			panic("unknown key")
+		}
+
+		prod.Quo(prod, g.Beta(g.nodes[0]))
+
+		sum.Add(sum, prod)
+	}
+
+	return sum, valid
+}
+
+func (g *Graph) LogInsideWeight(n *Node, filter [3]string, lambda, kappa *float64) float64 {
+	correct := g.opts.EnableCountCorrection
+
+	sumI := logZero
+
+	for _, i := range g.succ[n] {
+		if !i.valid {
+			continue
+		}
+
+		if filter[0] != "" && i.n.Key() != filter[0] {
+			continue
+		}
+
+		sumT := logZero
+		sumR := logZero
+
+		for _, rt := range g.succ[i] {
+			if !rt.valid {
+				continue
+			}
+
+			if rt.nType == SubNode {
+				if correct && filter[2] != "" || filter[1] != "" && rt.r.Key() != filter[1] {
+					continue
+				}
+
+				sumP := logZero
+
+				for _, p := range g.succ[rt] {
+					if !p.valid {
+						continue
+					}
+
+					prod := 0.0
+
+					for _, m := range g.succ[p] {
+						if !m.valid {
+							continue
+						}
+
+						prod += g.LogBeta(m)
+					}
+
+					sumP = logAdd(sumP, prod)
+				}
+
+				sumR = logAdd(sumR, sumP+g.logEdges[[2]*Node{i, rt}])
+			}
+
+			if rt.nType == FinalNode {
+				if correct && filter[1] != "" || filter[2] != "" && rt.t.Key() != filter[2] {
+					continue
+				}
+
+				sumT = logAdd(sumT, g.logEdges[[2]*Node{i, rt}])
+			}
+		}
+
+		if n.lambda != nil && n.kappa != nil {
+			if lambda == nil {
+				lambda = &n.logLambda
+			}
+
+			if kappa == nil {
+				kappa = &n.logKappa
+			}
+
+			sumT += *lambda
+			sumR += *kappa
+		}
+
+		sumI = logAdd(sumI, sumT+g.logEdges[[2]*Node{n, i}])
+		sumI = logAdd(sumI, sumR+g.logEdges[[2]*Node{n, i}])
+	}
+
+	return sumI
+}
+
+func (g *Graph) logCount(ms []*Node, inside func(*Node) float64) (float64, bool) {
+	sum := logZero
+	valid := false
+
+	for _, m := range ms {
+		if !m.valid {
+			continue
+		}
+
+		valid = true
+
+		sum = logAdd(sum, g.lAlpha[m]+inside(m)-g.LogBeta(g.nodes[0]))
+	}
+
+	return sum, valid
+}
+
+func (g *Graph) LogInsertionCount(feature, key string) (float64, bool) {
+	ms, ok := g.insertions[feature][key]
+
+	if !ok {
+		return logZero, ok
+	}
+
+	return g.logCount(ms, func(m *Node) float64 {
+		return g.LogInsideWeight(m, [3]string{key}, nil, nil)
+	})
+}
+
+func (g *Graph) LogReorderingCount(feature, key string) (float64, bool) {
+	ms, ok := g.reorderings[feature][key]
+
+	if !ok {
+		return logZero, ok
+	}
+
+	return g.logCount(ms, func(m *Node) float64 {
+		return g.LogInsideWeight(m, [3]string{"", key}, nil, nil)
+	})
+}
+
+func (g *Graph) LogTranslationCount(feature, key string) (float64, bool) {
+	ms, ok := g.translations[feature][key]
+
+	if !ok {
+		return logZero, ok
+	}
+
+	return g.logCount(ms, func(m *Node) float64 {
+		return g.LogInsideWeight(m, [3]string{"", "", key}, nil, nil)
+	})
+}
+
+func (g *Graph) LogLambdaCount(feature, key string) (float64, bool) {
+	ms, ok := g.lambda[feature][key]
+
+	if !ok {
+		return logZero, ok
+	}
+
+	zero := logZero
+
+	return g.logCount(ms, func(m *Node) float64 {
+		switch key {
+		case LambdaKey:
+			return g.LogInsideWeight(m, [3]string{}, nil, &zero)
+		case KappaKey:
+			return g.LogInsideWeight(m, [3]string{}, &zero, nil)
+		default:
+			panic("unknown key")
+		}
+	})
+}
+
+func (g *Graph) CollectCounts(nC, nR, nT, nL, nF *Count) {
+	g.CollectWeightedCounts(1, nC, nR, nT, nL, nF)
+}
+
+// CollectWeightedCountsByKey computes the expected count of every tracked
+// operation separately. It revisits a node once per key and is kept as the
+// reference for CollectWeightedCounts.
+func (g *Graph) CollectWeightedCountsByKey(w float64, nC, nR, nT, nL, nF *Count) {
+	if g.logSpace {
+		lw := math.Log(w)
+
+		weighted := func(count func(string, string) (float64, bool)) func(string, string) (float64, bool) {
+			if w == 1 {
+				return count
+			}
+
+			return func(feature, key string) (float64, bool) {
+				val, ok := count(feature, key)
+
+				return val + lw, ok
+			}
+		}
+
+		translationCount := weighted(g.LogTranslationCount)
+
+		nC.ForEachLog(g.insertions, weighted(g.LogInsertionCount))
+		nR.ForEachLog(g.reorderings, weighted(g.LogReorderingCount))
+		nT.ForEachLog(g.translations, func(feature, key string) (float64, bool) {
+			val, ok := translationCount(feature, key)
+
+			if !g.opts.EnablePhrasalTranslations {
+				return val, ok
+			}
+
+			if ok {
+				nF.AddLog(feature, fertilityKey(key), val)
+			}
+
+			return val, ok && key != NullToken
+		})
+
+		nL.ForEachLog(g.lambda, weighted(g.LogLambdaCount))
+
+		return
+	}
+
+	bw := big.NewFloat(w)
+
+	weighted := func(count func(string, string) (*big.Float, bool)) func(string, string) (*big.Float, bool) {
+		if w == 1 {
+			return count
+		}
+
+		return func(feature, key string) (*big.Float, bool) {
+			val, ok := count(feature, key)
+
+			if !ok {
+				return val, ok
+			}
+
+			return new(big.Float).Mul(val, bw), ok
+		}
+	}
+
+	translationCount := weighted(g.TranslationCount)
+
+	nC.ForEach(g.insertions, weighted(g.InsertionCount))
+	nR.ForEach(g.reorderings, weighted(g.ReorderingCount))
+	nT.ForEach(g.translations, func(feature, key string) (*big.Float, bool) {
+		val, ok := translationCount(feature, key)
+
+		if !g.opts.EnablePhrasalTranslations {
+			return val, ok
+		}
+
+		if ok {
+			nF.Add(feature, fertilityKey(key), val)
+		}
+
+		return val, ok && key != NullToken
+	})
+
+	nL.ForEach(g.lambda, weighted(g.LambdaCount))
+}
+
+func fertilityKey(key string) string {
+	if key == NullToken {
+		return "0"
+	}
+
+	return strconv.Itoa(len(strings.Split(key, " ")))
+}
+
+// CollectWeightedCounts adds the posterior of every hyperedge below a valid
+// major node to the counts of its operations. Each hyperedge is visited once.
+func (g *Graph) CollectWeightedCounts(w float64, nC, nR, nT, nL, nF *Count) {
+	if g.logSpace {
+		g.logCollectCounts(math.Log(w), nC, nR, nT, nL, nF)
+
+		return
+	}
+
+	counts := g.expectedCounts()
+
+	nC.Merge(counts[0], w)
+	nR.Merge(counts[1], w)
+	nL.Merge(counts[3], w)
+
+	phrasal := g.opts.EnablePhrasalTranslations
+
+	bw := big.NewFloat(w)
+
+	// translations of different lengths share a fertility key, so they are
+	// folded in a fixed order
+	features := counts[2].Features()
+
+	sort.Strings(features)
+
+	for _, feature := range features {
+		keys := counts[2].FeatureKeys(feature)
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			val := new(big.Float).Mul(counts[2].val[feature][key], bw)
+
+			if phrasal {
+				nF.Add(feature, fertilityKey(key), val)
+			}
+
+			if !phrasal || key != NullToken {
+				nT.Add(feature, key, val)
+			}
+		}
+	}
+}
+
+func (g *Graph) partitionWeight(r *Node) *big.Float {
+	sum := new(big.Float)
+
+	for _, p := range g.succ[r] {
+		if !p.valid {
+			continue
+		}
+
+		prod := big.NewFloat(1)
+
+		for _, m := range g.succ[p] {
+			if !m.valid {
+				continue
+			}
+
+			prod.Mul(prod, g.Beta(m))
+		}
+
+		sum.Add(sum, prod)
+	}
+
+	return sum
+}
+
+func (g *Graph) logPartitionWeight(r *Node) float64 {
+	if w, ok := g.lPartition[r]; ok {
+		return w
+	}
+
+	sum := logZero
+
+	for _, p := range g.succ[r] {
+		if !p.valid {
+			continue
+		}
+
+		prod := 0.0
+
+		for _, m := range g.succ[p] {
+			if !m.valid {
+				continue
+			}
+
+			prod += g.LogBeta(m)
+		}
+
+		sum = logAdd(sum, prod)
+	}
+
+	g.lPartition[r] = sum
+
+	return sum
+}
+
+// keySum is the summed posterior of the hyperedges with one operation key
+// below a major node and the number of these hyperedges.
+type keySum struct {
+	key      string
+	features []string
+	n        int
+	val      *big.Float
+	log      float64
+}
+
+type keySums []keySum
+
+func (s *keySums) get(key string, features []string) *keySum {
+	for i := range *s {
+		if (*s)[i].key == key {
+			(*s)[i].n++
+
+			return &(*s)[i]
+		}
+	}
+
+	*s = append(*s, keySum{key: key, features: features, n: 1, log: logZero})
+
+	return &(*s)[len(*s)-1]
+}
+
+func (s *keySums) add(key string, features []string, val *big.Float) {
+	ks := s.get(key, features)
+
+	if ks.val == nil {
+		ks.val = new(big.Float)
+	}
+
+	ks.val.Add(ks.val, val)
+}
+
+func (s *keySums) addLog(key string, features []string, val float64) {
+	ks := s.get(key, features)
+
+	ks.log = logAdd(ks.log, val)
+}
+
+// flush adds the sum of every key to c. Without count correction, each key
+// includes the hyperedges of the other edge kind and is counted once per
+// hyperedge that carries it, like the per-key counts.
+func (s keySums) flush(c *Count, other *big.Float, correct bool) {
+	for _, ks := range s {
+		val := new(big.Float).Set(ks.val)
+
+		if !correct {
+			val.Add(val, other)
+			val.Mul(val, big.NewFloat(float64(ks.n)))
+		}
+
+		for _, feature := range ks.features {
+			c.Add(feature, ks.key, val)
+		}
+	}
+}
+
+func (s keySums) flushLog(other float64, correct bool, add func(feature, key string, val float64)) {
+	for _, ks := range s {
+		val := ks.log
+
+		if !correct {
+			val = logAdd(val, other) + math.Log(float64(ks.n))
+		}
+
+		for _, feature := range ks.features {
+			add(feature, ks.key, val)
+		}
+	}
+}
+
+// expectedCounts returns the insertion, reordering, translation and lambda
+// counts of the graph. Invalid hyperedges contribute zero so that every
+// operation below a valid major node still gets an entry.
+func (g *Graph) expectedCounts() [4]*Count {
+	counts := [4]*Count{NewCount(), NewCount(), NewCount(), NewCount()}
+
+	correct := g.opts.EnableCountCorrection
+
+	z := g.Beta(g.nodes[0])
+
+	for _, n := range g.nodes {
+		if n.nType != MajorNode || !n.valid {
+			continue
+		}
+
+		scale := new(big.Float).Quo(g.pAlpha[n], z)
+		interior := n.lambda != nil && n.kappa != nil
+
+		sumT := new(big.Float)
+		sumR := new(big.Float)
+
+		var insertions, reorderings, translations keySums
+
+		for _, i := range g.succ[n] {
+			sumI := new(big.Float)
+
+			for _, rt := range g.succ[i] {
+				post := new(big.Float)
+
+				if i.valid && rt.valid {
+					post.Set(g.edges[[2]*Node{i, rt}])
+
+					if rt.nType == SubNode {
+						post.Mul(g.partitionWeight(rt), post)
+					}
+
+					if interior && rt.nType == SubNode {
+						post.Mul(post, n.kappa)
+					}
+
+					if interior && rt.nType == FinalNode {
+						post.Mul(post, n.lambda)
+					}
+
+					post.Mul(post, g.edges[[2]*Node{n, i}])
+					post.Mul(post, scale)
+				}
+
+				switch rt.nType {
+				case SubNode:
+					reorderings.add(rt.r.Key(), rt.r.Backoff(), post)
+					sumR.Add(sumR, post)
+				case FinalNode:
+					translations.add(rt.t.Key(), rt.t.Backoff(), post)
+					sumT.Add(sumT, post)
+				}
+
+				sumI.Add(sumI, post)
+			}
+
+			insertions.add(i.n.Key(), i.n.Backoff(), sumI)
+		}
+
+		insertions.flush(counts[0], new(big.Float), correct)
+		reorderings.flush(counts[1], sumT, correct)
+		translations.flush(counts[2], sumR, correct)
+
+		if len(n.tree.Children) != 0 {
+			eStr := n.Yield()
+
+			counts[3].Add(eStr, LambdaKey, sumT)
+			counts[3].Add(eStr, KappaKey, sumR)
+		}
+	}
+
+	return counts
+}
+
+// logCollectCounts is the log space variant of expectedCounts. It adds the
+// posteriors scaled by lw to the given counts directly.
+func (g *Graph) logCollectCounts(lw float64, nC, nR, nT, nL, nF *Count) {
+	phrasal := g.opts.EnablePhrasalTranslations
+	correct := g.opts.EnableCountCorrection
+
+	z := g.LogBeta(g.nodes[0])
+
+	var insertions, reorderings, translations keySums
+
+	for _, n := range g.nodes {
+		if n.nType != MajorNode || !n.valid {
+			continue
+		}
+
+		scale := g.lAlpha[n] - z + lw
+		interior := n.lambda != nil && n.kappa != nil
+
+		sumT := logZero
+		sumR := logZero
+
+		insertions, reorderings, translations = insertions[:0], reorderings[:0], translations[:0]
+
+		for _, i := range g.succ[n] {
+			sumI := logZero
+
+			for _, rt := range g.succ[i] {
+				post := logZero
+
+				if i.valid && rt.valid {
+					post = g.logEdges[[2]*Node{i, rt}]
+
+					if rt.nType == SubNode {
+						post += g.logPartitionWeight(rt)
+					}
+
+					if interior && rt.nType == SubNode {
+						post += n.logKappa
+					}
+
+					if interior && rt.nType == FinalNode {
+						post += n.logLambda
+					}
+
+					post += g.logEdges[[2]*Node{n, i}] + scale
+				}
+
+				switch rt.nType {
+				case SubNode:
+					reorderings.addLog(rt.r.Key(), rt.r.Backoff(), post)
+					sumR = logAdd(sumR, post)
+				case FinalNode:
+					translations.addLog(rt.t.Key(), rt.t.Backoff(), post)
+					sumT = logAdd(sumT, post)
+				}
+
+				sumI = logAdd(sumI, post)
+			}
+
+			insertions.addLog(i.n.Key(), i.n.Backoff(), sumI)
+		}
+
+		insertions.flushLog(logZero, correct, nC.AddLog)
+		reorderings.flushLog(sumT, correct, nR.AddLog)
+		translations.flushLog(sumR, correct, func(feature, key string, val float64) {
+			if phrasal {
+				nF.AddLog(feature, fertilityKey(key), val)
+			}
+
+			if !phrasal || key != NullToken {
+				nT.AddLog(feature, key, val)
+			}
+		})
+
+		if len(n.tree.Children) != 0 {
+			eStr := n.Yield()
+
+			nL.AddLog(eStr, LambdaKey, sumT)
+			nL.AddLog(eStr, KappaKey, sumR)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package ykm
+
+import (
+	"bufio"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// baselineCounts holds the expected counts of the first two training
+// iterations on the mock corpus, computed by the per-key implementation
+// before the log space backend and the single pass were added.
+const baselineCounts = "../test/mono-ykm_mock_counts.tsv"
+
+var tables = []string{"n", "r", "t", "l", "f"}
+
+func byKey(g *Graph, w float64, c [5]*Count) {
+	g.CollectWeightedCountsByKey(w, c[0], c[1], c[2], c[3], c[4])
+}
+
+func singlePass(g *Graph, w float64, c [5]*Count) {
+	g.CollectWeightedCounts(w, c[0], c[1], c[2], c[3], c[4])
+}
+
+func hasCount(c *Count, feature, key string) bool {
+	for _, k := range c.FeatureKeys(feature) {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestCollectWeightedCounts(t *testing.T) {
+	for _, logSpace := range []bool{false, true} {
+		for _, phrasal := range []bool{false, true} {
+			for _, correct := range []bool{false, true} {
+				f := newFixture(t, func(o *Options) {
+					o.LogSpaceArithmetic = logSpace
+					o.EnableInteriorInsertions = phrasal
+					o.EnablePhrasalTranslations = phrasal
+					o.PhraseLengthLimit = 2
+					o.EnableCountCorrection = correct
+				})
+
+				m := f.model()
+
+				want := f.collect(m, byKey)
+				got := f.collect(m, singlePass)
+
+				for i, table := range tables {
+					for _, feature := range want[i].Features() {
+						if a, b := len(want[i].FeatureKeys(feature)), len(got[i].FeatureKeys(feature)); a != b {
+							t.Errorf("log space: %t phrasal: %t correct: %t table %s [%s]: %d keys by key, %d in single pass", logSpace, phrasal, correct, table, feature, a, b)
+						}
+
+						for _, key := range want[i].FeatureKeys(feature) {
+							x := want[i].GetLog(feature, key)
+							y := got[i].GetLog(feature, key)
+
+							if math.IsInf(x, -1) && math.IsInf(y, -1) {
+								continue
+							}
+
+							if math.Abs(x-y) > 1e-9 {
+								t.Errorf("log space: %t phrasal: %t correct: %t count %s [%s : %s]: by key %e, single pass %e", logSpace, phrasal, correct, table, feature, key, x, y)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestBaselineCounts(t *testing.T) {
+	file, err := os.Open(baselineCounts)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	type count struct {
+		iteration int
+		table     int
+		feature   string
+		key       string
+		val       float64
+	}
+
+	baseline := make(map[string][]count)
+
+	scanner := bufio.NewScanner(file)
+
+	scanner.Scan()
+
+	for scanner.Scan() {
+		record := strings.Split(scanner.Text(), "\t")
+
+		iteration, err := strconv.Atoi(record[1])
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		val, err := strconv.ParseFloat(record[5], 64)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		table := strings.Index("nrtlf", record[2])
+
+		baseline[record[0]] = append(baseline[record[0]], count{iteration, table, record[3], record[4], val})
+	}
+
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	configure := map[string]func(*Options){
+		"terminal": func(o *Options) {
+			o.EnablePhrasalTranslations = false
+		},
+		"phrasal": func(o *Options) {
+			o.EnableInteriorInsertions = true
+			o.EnablePhrasalTranslations = true
+			o.PhraseLengthLimit = 2
+			o.MaxPhraseLengthDifference = 1
+		},
+	}
+
+	for name, counts := range baseline {
+		for _, logSpace := range []bool{false, true} {
+			f := newFixture(t, func(o *Options) {
+				configure[name](o)
+
+				o.LogSpaceArithmetic = logSpace
+			})
+
+			got := [][5]*Count{f.collect(f.model(), singlePass), f.collect(f.train(), singlePass)}
+
+			for _, c := range counts {
+				if !hasCount(got[c.iteration-1][c.table], c.feature, c.key) {
+					t.Errorf("%s log space: %t iteration %d count %s [%s : %s]: missing", name, logSpace, c.iteration, tables[c.table], c.feature, c.key)
+
+					continue
+				}
+
+				x := got[c.iteration-1][c.table].GetLog(c.feature, c.key)
+
+				if c.val == 0 && math.IsInf(x, -1) {
+					continue
+				}
+
+				if math.Abs(x-math.Log(c.val)) > 1e-9 {
+					t.Errorf("%s log space: %t iteration %d count %s [%s : %s] = %e, want %e", name, logSpace, c.iteration, tables[c.table], c.feature, c.key, math.Exp(x), c.val)
+				}
+			}
+		}
+	}
+}
+
+// logTotal returns the log of the sum of all values of c.
+func logTotal(c *Count) float64 {
+	sum := logZero
+
+	for _, feature := range c.Features() {
+		for _, key := range c.FeatureKeys(feature) {
+			sum = logAdd(sum, c.GetLog(feature, key))
+		}
+	}
+
+	return sum
+}
+
+// TestCountCorrection checks that each major node contributes its posterior
+// once to the insertion counts and once to the reordering and translation
+// counts. The uncorrected counts include the other edge kind and count some
+// operations repeatedly.
+func TestCountCorrection(t *testing.T) {
+	for _, logSpace := range []bool{false, true} {
+		for _, phrasal := range []bool{false, true} {
+			f := newFixture(t, func(o *Options) {
+				o.LogSpaceArithmetic = logSpace
+				o.EnableInteriorInsertions = phrasal
+				o.EnablePhrasalTranslations = phrasal
+				o.PhraseLengthLimit = 2
+				o.EnableCountCorrection = true
+			})
+
+			m := f.model()
+
+			graphs, _ := f.graphs(m)
+
+			for _, g := range graphs {
+				z := g.LogProbability()
+
+				visits := logZero
+
+				for _, n := range g.nodes {
+					if n.nType != MajorNode || !n.valid {
+						continue
+					}
+
+					if logSpace {
+						visits = logAdd(visits, g.LogAlpha(n)+g.LogBeta(n)-z)
+					} else {
+						visits = logAdd(visits, LogOf(g.Alpha(n))+LogOf(g.Beta(n))-z)
+					}
+				}
+
+				for _, collect := range []func(*Graph, float64, [5]*Count){byKey, singlePass} {
+					c := f.counts()
+
+					collect(g, 1, c)
+
+					// phrasal translations move NULL translations to the
+					// fertility counts, which hold every translation
+					translations := c[2]
+
+					if phrasal {
+						translations = c[4]
+					}
+
+					if x := logTotal(c[0]); math.Abs(x-visits) > 1e-9 {
+						t.Errorf("log space: %t phrasal: %t insertion counts sum to %e, want %e", logSpace, phrasal, math.Exp(x), math.Exp(visits))
+					}
+
+					if x := logAdd(logTotal(c[1]), logTotal(translations)); math.Abs(x-visits) > 1e-9 {
+						t.Errorf("log space: %t phrasal: %t reordering and translation counts sum to %e, want %e", logSpace, phrasal, math.Exp(x), math.Exp(visits))
+					}
+				}
+			}
+		}
+	}
+}
+
+func benchmarkCollect(b *testing.B, logSpace bool, collect func(*Graph, float64, [5]*Count)) {
+	f := newFixture(b, func(o *Options) {
+		o.LogSpaceArithmetic = logSpace
+	})
+
+	graphs, samples := f.graphs(f.model())
+
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		counts := f.counts()
+
+		for i, g := range graphs {
+			collect(g, samples[i].Weight, counts)
+		}
+	}
+}
+
+func BenchmarkCollectWeightedCountsByKey(b *testing.B) {
+	b.Run("big", func(b *testing.B) { benchmarkCollect(b, false, byKey) })
+	b.Run("log", func(b *testing.B) { benchmarkCollect(b, true, byKey) })
+}
+
+func BenchmarkCollectWeightedCounts(b *testing.B) {
+	b.Run("big", func(b *testing.B) { benchmarkCollect(b, false, singlePass) })
+	b.Run("log", func(b *testing.B) { benchmarkCollect(b, true, singlePass) })
+}
@@ -0,0 +1,154 @@
+package ykm
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+)
+
+func CrossCheck(m *Model, name string, tolerance float64, out io.Writer) (int, int, error) {
+	corpus, err := NewIterator(name, m.opts.CorpusFormat)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	defer corpus.Close()
+
+	backend := func(logSpace bool) *Model {
+		c := *m
+
+		c.opts.LogSpaceArithmetic = logSpace
+
+		return &c
+	}
+
+	bigBackend := backend(false)
+	logBackend := backend(true)
+
+	mismatches := 0
+
+	check := func(name string, a, b float64) {
+		if math.IsInf(a, -1) && math.IsInf(b, -1) {
+			return
+		}
+
+		if math.Abs(a-b) <= tolerance {
+			return
+		}
+
+		mismatches++
+
+		fmt.Fprintf(out, "Mismatch %s (big: %e log: %e)\n", name, a, b)
+	}
+
+	checkTable := func(name string, b, l map[string]map[string]*big.Float) {
+		if len(b) != len(l) {
+			mismatches++
+
+			fmt.Fprintf(out, "Mismatch %s (big: %d features log: %d features)\n", name, len(b), len(l))
+		}
+
+		for feature, keys := range b {
+			if len(keys) != len(l[feature]) {
+				mismatches++
+
+				fmt.Fprintf(out, "Mismatch %s [%s] (big: %d keys log: %d keys)\n", name, feature, len(keys), len(l[feature]))
+			}
+
+			for key, val := range keys {
+				p, ok := l[feature][key]
+
+				if !ok {
+					mismatches++
+
+					fmt.Fprintf(out, "Mismatch %s [%s : %s] (missing in log backend)\n", name, feature, key)
+
+					continue
+				}
+
+				check(fmt.Sprintf("%s [%s : %s]", name, feature, key), LogOf(val), LogOf(p))
+			}
+		}
+	}
+
+	bigCounts := []*Count{NewCount(), NewCount(), NewCount(), NewCount(), NewCount()}
+	logCounts := []*Count{NewLogCount(), NewLogCount(), NewLogCount(), NewLogCount(), NewLogCount()}
+
+	counted := 0
+
+	for corpus.Next() && (m.opts.TrainingSampleLimit == -1 || counted < m.opts.TrainingSampleLimit) {
+		if !corpus.Sample().Positive() {
+			continue
+		}
+
+		sample := corpus.Sample()
+
+		mt, e, err := m.InitSample(sample)
+
+		if err != nil {
+			continue
+		}
+
+		gb, errBig := NewGraph(mt, e, bigBackend)
+		gl, errLog := NewGraph(mt, e, logBackend)
+
+		if (errBig == nil) != (errLog == nil) {
+			mismatches++
+
+			fmt.Fprintf(out, "Mismatch %s (big: %v log: %v)\n", sample.ID, errBig, errLog)
+
+			continue
+		}
+
+		if errBig != nil {
+			continue
+		}
+
+		check(sample.ID, gb.LogProbability(), gl.LogProbability())
+
+		gb.CollectCounts(bigCounts[0], bigCounts[1], bigCounts[2], bigCounts[3], bigCounts[4])
+		gl.CollectCounts(logCounts[0], logCounts[1], logCounts[2], logCounts[3], logCounts[4])
+
+		fmt.Fprintf(out, "Checked sample %s [%e]\n", sample.ID, gl.LogProbability())
+
+		counted++
+	}
+
+	if err := corpus.Error(); err != nil && err != io.EOF {
+		return counted, mismatches, err
+	}
+
+	for i, name := range []string{"n", "r", "t", "l", "f"} {
+		for feature, keys := range bigCounts[i].val {
+			for key := range keys {
+				check(fmt.Sprintf("count %s [%s : %s]", name, feature, key), bigCounts[i].GetLog(feature, key), logCounts[i].GetLog(feature, key))
+			}
+		}
+	}
+
+	if m.opts.EnableFertilityDecomposition {
+		DecomposeTranslationCount(bigCounts[2])
+		DecomposeTranslationCount(logCounts[2])
+	}
+
+	bigModel := NewModel(m.opts)
+	logModel := NewModel(m.opts)
+
+	if err := bigModel.UpdateWeights(bigCounts[0], bigCounts[1], bigCounts[2], bigCounts[3], bigCounts[4]); err != nil {
+		return counted, mismatches, fmt.Errorf("error updating big model weights: %w", err)
+	}
+
+	if err := logModel.UpdateWeights(logCounts[0], logCounts[1], logCounts[2], logCounts[3], logCounts[4]); err != nil {
+		return counted, mismatches, fmt.Errorf("error updating log model weights: %w", err)
+	}
+
+	checkTable("n", bigModel.n, logModel.n)
+	checkTable("r", bigModel.r, logModel.r)
+	checkTable("t", bigModel.t, logModel.t)
+	checkTable("l", bigModel.l, logModel.l)
+	checkTable("f", bigModel.f, logModel.f)
+
+	return counted, mismatches, nil
+}
@@ -0,0 +1,29 @@
+package ykm
+
+import (
+	"io"
+	"testing"
+)
+
+func TestCrossCheck(t *testing.T) {
+	for _, phrasal := range []bool{false, true} {
+		f := newFixture(t, func(o *Options) {
+			o.EnablePhrasalTranslations = phrasal
+			o.PhraseLengthLimit = 2
+		})
+
+		counted, mismatches, err := CrossCheck(f.train(), mockCorpus, 1e-9, io.Discard)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if counted != 2 {
+			t.Errorf("phrasal: %t expected 2 checked samples but got %d", phrasal, counted)
+		}
+
+		if mismatches != 0 {
+			t.Errorf("phrasal: %t expected no mismatches between big.Float and log space but got %d", phrasal, mismatches)
+		}
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package ykm
 
 import (
 	"fmt"
@@ -10,7 +10,7 @@ import (
 func (g *Graph) Draw(stubs ...string) (int, error) {
 	name := fmt.Sprintf("graph_%s.dot", strings.Join(stubs, "-"))
 
-	f, err := os.Create(filepath.Join(Config.GraphExportDirectory, name))
+	f, err := os.Create(filepath.Join(g.opts.GraphExportDirectory, name))
 
 	if err != nil {
 		return 0, err
@@ -33,7 +33,11 @@ func (g *Graph) Draw(stubs ...string) (int, error) {
 			sb.WriteString(fmt.Sprintf("%s ", n.tree.Label))
 			sb.WriteString(fmt.Sprintf("| %s ", n.tree.Sentence()))
 			sb.WriteString(fmt.Sprintf("| %s ", n.Substring()))
-			sb.WriteString(fmt.Sprintf("| { α: %e | β: %e }", g.pAlpha[n], g.pBeta[n]))
+			if g.logSpace {
+				sb.WriteString(fmt.Sprintf("| { log α: %e | log β: %e }", g.lAlpha[n], g.lBeta[n]))
+			} else {
+				sb.WriteString(fmt.Sprintf("| { α: %e | β: %e }", g.pAlpha[n], g.pBeta[n]))
+			}
 
 			if n.lambda != nil && n.kappa != nil {
 				sb.WriteString(fmt.Sprintf("| { λ: %e | κ: %e }", n.lambda, n.kappa))
@@ -83,6 +87,10 @@ func (g *Graph) Draw(stubs ...string) (int, error) {
 		sb.WriteString(fmt.Sprintf("  PTR%p -> PTR%p [label=\"%e\"]\n", k[0], k[1], v))
 	}
 
+	for k, v := range g.logEdges {
+		sb.WriteString(fmt.Sprintf("  PTR%p -> PTR%p [label=\"log %e\"]\n", k[0], k[1], v))
+	}
+
 	sb.WriteString("}\n")
 
 	return f.WriteString(sb.String())
@@ -0,0 +1,258 @@
+package ykm
+
+import (
+	"bufio"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const ModelFormatVersion = 2
+
+type ModelInfo struct {
+	Version    int
+	Iteration  int
+	Config     Options
+	CorpusPath string
+	CorpusHash string
+	Vocabulary map[string]int
+	Lengths    []LengthCount
+	Converted  bool
+}
+
+// modelFile is the gob encoding of a model. Gob writes maps in random order,
+// so since version 2 the vocabulary and the tables are written as sorted
+// slices and equal models encode to equal bytes. Version 1 files stored the
+// tables in the maps N to F.
+type modelFile struct {
+	Info ModelInfo
+
+	Vocabulary []vocabularyEntry
+	Tables     []tableEntry
+
+	N map[string]map[string]*big.Float
+	R map[string]map[string]*big.Float
+	T map[string]map[string]*big.Float
+	L map[string]map[string]*big.Float
+	F map[string]map[string]*big.Float
+}
+
+type vocabularyEntry struct {
+	Token string
+	Count int
+}
+
+type tableEntry struct {
+	Table   string
+	Feature string
+	Key     string
+	P       *big.Float
+}
+
+func newModelFile(m *Model) modelFile {
+	mf := modelFile{Info: m.info}
+
+	mf.Info.Vocabulary = nil
+
+	for _, token := range sortedKeys(m.info.Vocabulary) {
+		mf.Vocabulary = append(mf.Vocabulary, vocabularyEntry{token, m.info.Vocabulary[token]})
+	}
+
+	tables := m.Tables()
+
+	for _, name := range []string{"n", "r", "t", "l", "f"} {
+		t := tables[name]
+
+		for _, feature := range sortedKeys(t) {
+			for _, key := range sortedKeys(t[feature]) {
+				mf.Tables = append(mf.Tables, tableEntry{name, feature, key, t[feature][key]})
+			}
+		}
+	}
+
+	return mf
+}
+
+// restore copies the info and tables of the file into m.
+func (mf modelFile) restore(m *Model) error {
+	m.info = mf.Info
+
+	if len(mf.Vocabulary) > 0 {
+		m.info.Vocabulary = make(map[string]int, len(mf.Vocabulary))
+
+		for _, v := range mf.Vocabulary {
+			m.info.Vocabulary[v.Token] = v.Count
+		}
+	}
+
+	for _, t := range []struct {
+		dst *map[string]map[string]*big.Float
+		src map[string]map[string]*big.Float
+	}{{&m.n, mf.N}, {&m.r, mf.R}, {&m.t, mf.T}, {&m.l, mf.L}, {&m.f, mf.F}} {
+		if t.src != nil {
+			*t.dst = t.src
+		}
+	}
+
+	tables := m.Tables()
+
+	for _, e := range mf.Tables {
+		t, ok := tables[e.Table]
+
+		if !ok {
+			return fmt.Errorf("unknown table: %s", e.Table)
+		}
+
+		if t[e.Feature] == nil {
+			t[e.Feature] = make(map[string]*big.Float)
+		}
+
+		t[e.Feature][e.Key] = e.P
+	}
+
+	return nil
+}
+
+const FormatGob = "gob"
+const FormatTSV = "tsv"
+const FormatJSON = "json"
+
+const tsvInfoPrefix = "# "
+
+type jsonModel struct {
+	Info   ModelInfo                               `json:"info"`
+	Tables map[string]map[string]map[string]string `json:"tables"`
+}
+
+func Export(m *Model, stubs ...string) error {
+	format := m.opts.ModelExportFormat
+
+	if format != FormatGob && format != FormatTSV && format != FormatJSON {
+		return fmt.Errorf("unknown model export format: %s", format)
+	}
+
+	name := fmt.Sprintf("model_%s.%s", strings.Join(stubs, "-"), format)
+	file, err := os.Create(filepath.Join(m.opts.ModelExportDirectory, name))
+
+	if err != nil {
+		return fmt.Errorf("error creating file: %w", err)
+	}
+
+	defer file.Close()
+
+	m.info.Version = ModelFormatVersion
+
+	switch format {
+	case FormatTSV:
+		return exportTSV(m, file)
+	case FormatJSON:
+		return exportJSON(m, file)
+	}
+
+	enc := gob.NewEncoder(file)
+
+	if err := enc.Encode(newModelFile(m)); err != nil {
+		return fmt.Errorf("error encoding model: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Model) Tables() map[string]map[string]map[string]*big.Float {
+	return map[string]map[string]map[string]*big.Float{
+		"n": m.n,
+		"r": m.r,
+		"t": m.t,
+		"l": m.l,
+		"f": m.f,
+	}
+}
+
+// exportTSV writes the model info as a JSON comment line in front of the
+// header row, followed by one row per probability.
+func exportTSV(m *Model, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	info, err := json.Marshal(m.info)
+
+	if err != nil {
+		return fmt.Errorf("error encoding model info: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(bw, "%s%s\n", tsvInfoPrefix, info); err != nil {
+		return fmt.Errorf("error writing model info: %w", err)
+	}
+
+	if _, err := bw.WriteString("table\tfeature\tkey\tprobability\n"); err != nil {
+		return fmt.Errorf("error writing table: %w", err)
+	}
+
+	tables := m.Tables()
+
+	for _, name := range []string{"n", "r", "t", "l", "f"} {
+		t := tables[name]
+
+		for _, feature := range sortedKeys(t) {
+			for _, key := range sortedKeys(t[feature]) {
+				if strings.ContainsAny(feature+key, "\t\n") {
+					return fmt.Errorf("unexpected tab or newline in %s [%s : %s]", name, feature, key)
+				}
+
+				if _, err := fmt.Fprintf(bw, "%s\t%s\t%s\t%s\n", name, feature, key, t[feature][key].Text('g', -1)); err != nil {
+					return fmt.Errorf("error writing table: %w", err)
+				}
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+func exportJSON(m *Model, w io.Writer) error {
+	jm := jsonModel{
+		Info:   m.info,
+		Tables: make(map[string]map[string]map[string]string),
+	}
+
+	for name, t := range m.Tables() {
+		jm.Tables[name] = make(map[string]map[string]string, len(t))
+
+		for feature, keys := range t {
+			jm.Tables[name][feature] = make(map[string]string, len(keys))
+
+			for key, p := range keys {
+				jm.Tables[name][feature][key] = p.Text('g', -1)
+			}
+		}
+	}
+
+	enc := json.NewEncoder(w)
+
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(jm); err != nil {
+		return fmt.Errorf("error encoding model: %w", err)
+	}
+
+	return nil
+}
+
+func sortedKeys(m interface{}) []string {
+	v := reflect.ValueOf(m)
+	keys := make([]string, 0, v.Len())
+
+	for _, k := range v.MapKeys() {
+		keys = append(keys, k.String())
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
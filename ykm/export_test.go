@@ -0,0 +1,101 @@
+package ykm
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportReproducible(t *testing.T) {
+	for _, format := range []string{FormatGob, FormatTSV, FormatJSON} {
+		files := make([][]byte, 0)
+
+		// the export directory is part of the recorded config
+		dir := t.TempDir()
+
+		for run := 0; run < 2; run++ {
+			f := newFixture(t, func(o *Options) {
+				o.EnableReproducibleTraining = true
+				o.ConcurrentSampleEvaluations = 4
+				o.ExportModel = true
+				o.ModelExportDirectory = dir
+				o.ModelExportFormat = format
+			})
+
+			m := f.train()
+
+			name := filepath.Join(dir, "model_1."+format)
+
+			data, err := os.ReadFile(name)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			files = append(files, data)
+
+			imported, err := LoadModel(name, f.opts)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want, _ := Checksum(m)
+			got, _ := Checksum(imported)
+
+			if got != want {
+				t.Errorf("%s: checksum of the imported model %s, want %s", format, got, want)
+			}
+		}
+
+		if !bytes.Equal(files[0], files[1]) {
+			t.Errorf("%s: two runs exported different files", format)
+		}
+	}
+}
+
+func TestLoadModelWarnings(t *testing.T) {
+	dir := t.TempDir()
+
+	f := newFixture(t, func(o *Options) {
+		o.ExportModel = true
+		o.ModelExportDirectory = dir
+	})
+
+	f.train()
+
+	name := filepath.Join(dir, "model_1")
+
+	m, err := LoadModel(name, f.opts)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.Warnings()) != 0 {
+		t.Errorf("got warnings %v for the training config", m.Warnings())
+	}
+
+	opts := f.opts
+
+	opts.EnableInteriorInsertions = !opts.EnableInteriorInsertions
+
+	if m, err = LoadModel(name, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := m.Warnings(); len(w) != 1 || !strings.HasPrefix(w[0], "ENABLE_INTERIOR_INSERTIONS") {
+		t.Errorf("got warnings %v, want one for ENABLE_INTERIOR_INSERTIONS", w)
+	}
+
+	opts = f.opts
+
+	opts.EnablePhrasalTranslations = true
+	opts.PhraseLengthLimit = 2
+
+	if _, err := LoadModel(name, opts); err == nil {
+		t.Error("expected an error enabling phrasal translations")
+	}
+}
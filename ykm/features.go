@@ -0,0 +1,216 @@
+package ykm
+
+import (
+	"fmt"
+	"github.com/jonasknobloch/jinn/pkg/tree"
+	"strconv"
+	"strings"
+)
+
+func nFeature(p, st *tree.Tree, replaceLeafs bool) string {
+	var feature string
+	var label string
+
+	if replaceLeafs && len(st.Children) == 0 {
+		label = UnknownToken
+	} else {
+		label = st.Label
+	}
+
+	if p == nil {
+		feature = "ROOT " + label
+	} else {
+		feature = p.Label + " " + label
+	}
+
+	return feature
+}
+
+func rFeature(st *tree.Tree, replaceLeafs bool) string {
+	if len(st.Children) == 0 {
+		return ""
+	}
+
+	var feature string
+
+	for _, c := range st.Children {
+		feature += " "
+
+		if replaceLeafs && len(c.Children) == 0 {
+			feature += UnknownToken
+		} else {
+			feature += c.Label
+		}
+	}
+
+	return feature[1:]
+}
+
+func tFeature(st *tree.Tree, replaceLeafs bool) string {
+	var sb strings.Builder
+
+	for i, leaf := range st.Leaves() {
+		if i > 0 {
+			sb.WriteString(" ")
+		}
+
+		if replaceLeafs {
+			sb.WriteString(UnknownToken)
+		} else {
+			sb.WriteString(leaf.Label)
+		}
+	}
+
+	return sb.String()
+}
+
+const LevelExact = "exact"
+const LevelUnknown = "unknown"
+const LevelNode = "node"
+const LevelParentArity = "parent-arity"
+const LevelArity = "arity"
+const LevelPOS = "pos"
+const LevelTag = "tag"
+
+var backoffLevels = [3][]string{
+	InsertionFeature:   {LevelExact, LevelUnknown, LevelTag, LevelNode},
+	ReorderingFeature:  {LevelExact, LevelUnknown, LevelTag, LevelParentArity, LevelArity},
+	TranslationFeature: {LevelExact, LevelUnknown, LevelTag, LevelPOS},
+}
+
+func parseBackoff(s string) []string {
+	levels := make([]string, 0)
+
+	for _, level := range strings.Split(s, ",") {
+		if level = strings.TrimSpace(level); level != "" {
+			levels = append(levels, level)
+		}
+	}
+
+	return levels
+}
+
+func checkBackoff(s string, nf NodeFeature) error {
+	levels := parseBackoff(s)
+
+	if len(levels) == 0 || levels[0] != LevelExact {
+		return fmt.Errorf("backoff chain must start with %s", LevelExact)
+	}
+
+	seen := make(map[string]bool, len(levels))
+
+	for _, level := range levels {
+		known := false
+
+		for _, l := range backoffLevels[nf] {
+			known = known || l == level
+		}
+
+		if !known {
+			return fmt.Errorf("unknown backoff level: %s", level)
+		}
+
+		if seen[level] {
+			return fmt.Errorf("duplicate backoff level: %s", level)
+		}
+
+		seen[level] = true
+	}
+
+	return nil
+}
+
+func backoffFeature(level string, nf NodeFeature, p, st *tree.Tree, exact, unknown [3]string) string {
+	switch level {
+	case LevelExact:
+		return exact[nf]
+	case LevelUnknown:
+		return unknown[nf]
+	case LevelNode:
+		return "$NODE$ " + st.Label
+	case LevelParentArity:
+		return "$ARITY$ " + st.Label + " " + strconv.Itoa(len(st.Children))
+	case LevelArity:
+		return "$ARITY$ " + strconv.Itoa(len(st.Children))
+	case LevelPOS:
+		return "$POS$ " + posFeature(p, st)
+	case LevelTag:
+		return "$TAG$ " + tagFeature(nf, p, st)
+	default:
+		panic("unknown backoff level")
+	}
+}
+
+// tag strips the dependency relation from interior labels of the form
+// relation/POS as built by DependencyTree. Leaves keep their label.
+func tag(st *tree.Tree) string {
+	if len(st.Children) == 0 {
+		return st.Label
+	}
+
+	return tagLabel(st.Label)
+}
+
+func tagLabel(label string) string {
+	if i := strings.LastIndex(label, "/"); i != -1 {
+		return label[i+1:]
+	}
+
+	return label
+}
+
+func tagFeature(nf NodeFeature, p, st *tree.Tree) string {
+	switch nf {
+	case InsertionFeature:
+		if p == nil {
+			return "ROOT " + tag(st)
+		}
+
+		return tag(p) + " " + tag(st)
+	case ReorderingFeature:
+		tags := make([]string, len(st.Children))
+
+		for i, c := range st.Children {
+			tags[i] = tag(c)
+		}
+
+		return strings.Join(tags, " ")
+	default:
+		tags := strings.Split(posFeature(p, st), " ")
+
+		for i, t := range tags {
+			tags[i] = tagLabel(t)
+		}
+
+		return strings.Join(tags, " ")
+	}
+}
+
+func posFeature(p, st *tree.Tree) string {
+	var sb strings.Builder
+
+	var walk func(p, st *tree.Tree)
+	walk = func(p, st *tree.Tree) {
+		if len(st.Children) == 0 {
+			if sb.Len() > 0 {
+				sb.WriteString(" ")
+			}
+
+			if p == nil {
+				sb.WriteString(UnknownToken)
+			} else {
+				sb.WriteString(p.Label)
+			}
+
+			return
+		}
+
+		for _, c := range st.Children {
+			walk(st, c)
+		}
+	}
+
+	walk(p, st)
+
+	return sb.String()
+}
@@ -0,0 +1,110 @@
+package ykm
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCheckBackoff(t *testing.T) {
+	tests := []struct {
+		chain string
+		nf    NodeFeature
+		err   string
+	}{
+		{"exact", InsertionFeature, ""},
+		{"exact, unknown, tag, node", InsertionFeature, ""},
+		{"exact,tag,parent-arity,arity", ReorderingFeature, ""},
+		{"exact,pos", TranslationFeature, ""},
+		{"", TranslationFeature, "must start with exact"},
+		{"tag,exact", InsertionFeature, "must start with exact"},
+		{"exact,pos", InsertionFeature, "unknown backoff level: pos"},
+		{"exact,node", TranslationFeature, "unknown backoff level: node"},
+		{"exact,tag,tag", ReorderingFeature, "duplicate backoff level: tag"},
+	}
+
+	for _, tt := range tests {
+		err := checkBackoff(tt.chain, tt.nf)
+
+		if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("chain %q: got error %v, want %q", tt.chain, err, tt.err)
+		}
+	}
+
+	opts := DefaultOptions()
+
+	opts.TranslationBackoff = "exact,node"
+
+	if err := opts.Validate(); err == nil || !strings.Contains(err.Error(), "invalid TRANSLATION_BACKOFF") {
+		t.Errorf("got error %v, want an invalid TRANSLATION_BACKOFF", err)
+	}
+}
+
+func TestBackoffFeatures(t *testing.T) {
+	st, err := DecodeTree(conlluSentence, FormatCoNLLU)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+
+	opts.InsertionBackoff = "exact,tag,node"
+	opts.ReorderingBackoff = "exact,tag,parent-arity,arity"
+	opts.TranslationBackoff = "exact,tag,pos"
+
+	mt := NewMetaTree(st)
+
+	mt.CollectFeatures(opts)
+
+	subject := st.Children[0]
+
+	tests := []struct {
+		nf   NodeFeature
+		want []string
+	}{
+		{InsertionFeature, []string{"root/VERB nsubj/NOUN", "$TAG$ VERB NOUN", "$NODE$ nsubj/NOUN"}},
+		{ReorderingFeature, []string{"det/DET head/NOUN", "$TAG$ DET NOUN", "$ARITY$ nsubj/NOUN 2", "$ARITY$ 2"}},
+		{TranslationFeature, []string{"The cat", "$TAG$ DET NOUN", "$POS$ det/DET head/NOUN"}},
+	}
+
+	for _, tt := range tests {
+		if got := mt.Feature(subject, tt.nf).Backoff; strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("feature %d: got chain %q, want %q", tt.nf, got, tt.want)
+		}
+	}
+}
+
+func TestTrainBackoff(t *testing.T) {
+	corpus := writeCorpus(t, "corpus.conllu", "# sent_id = a\n# target = a cat sleeps\n# label = 1\n"+conlluSentence)
+
+	// the unseen relation backs off to the POS tags of the seen one
+	unseen := &Sample{ID: "unseen", Tree: strings.Replace(conlluSentence, "\tdet\t", "\tdep\t", 1), Sentence: "a cat sleeps"}
+
+	for _, chain := range []string{"exact", "exact,tag"} {
+		f := newFixture(t, func(o *Options) {
+			o.TrainingDataPath = corpus
+			o.InsertionBackoff = chain
+			o.ReorderingBackoff = chain
+			o.TranslationBackoff = chain
+		})
+
+		m := f.train()
+
+		mt, e, err := m.InitSample(unseen)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		g, err := NewGraph(mt, e, m)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if reachable := !math.IsInf(g.LogProbability(), -1); reachable != (chain != "exact") {
+			t.Errorf("chain %s: got log probability %v for an unseen relation", chain, g.LogProbability())
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package ykm
+
+import (
+	"math/big"
+	"strings"
+)
+
+func DecomposeTranslationCount(count *Count) {
+	if count.logSpace {
+		decomposeLogTranslationCount(count)
+
+		return
+	}
+
+	for _, feature := range count.Features() {
+		for _, key := range count.FeatureKeys(feature) {
+			val := count.val[feature][key]
+			target := strings.Split(key, " ")
+
+			if len(target) == 1 {
+				continue
+			}
+
+			p := new(big.Float).Copy(val)
+
+			if _, err := Root(p, len(target)); err != nil {
+				// TODO estimate x^(1/n)
+			}
+
+			for _, token := range target {
+				count.Add(feature, token, p)
+			}
+
+			count.rwm.Lock()
+
+			delete(count.val[feature], key)
+
+			if len(count.val[feature]) == 0 {
+				delete(count.val, feature)
+			}
+
+			count.rwm.Unlock()
+		}
+	}
+}
+
+func decomposeLogTranslationCount(count *Count) {
+	for _, feature := range count.Features() {
+		for _, key := range count.FeatureKeys(feature) {
+			val := count.log[feature][key]
+			target := strings.Split(key, " ")
+
+			if len(target) == 1 {
+				continue
+			}
+
+			p := val / float64(len(target))
+
+			for _, token := range target {
+				count.AddLog(feature, token, p)
+			}
+
+			count.rwm.Lock()
+
+			delete(count.log[feature], key)
+
+			if len(count.log[feature]) == 0 {
+				delete(count.log, feature)
+			}
+
+			count.rwm.Unlock()
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package ykm
+
+import (
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const mockCorpus = "../test/mono-ykm_mock.tsv"
+
+// fixture builds models, graphs and counts on the mock corpus.
+type fixture struct {
+	tb   testing.TB
+	opts Options
+}
+
+func newFixture(tb testing.TB, configure func(*Options)) *fixture {
+	opts := DefaultOptions()
+
+	opts.TrainingDataPath = mockCorpus
+	opts.ExportModel = false
+
+	if configure != nil {
+		configure(&opts)
+	}
+
+	return &fixture{tb: tb, opts: opts}
+}
+
+// model returns an untrained model that knows the corpus statistics.
+func (f *fixture) model() *Model {
+	f.tb.Helper()
+
+	m := NewModel(f.opts)
+
+	if err := m.Prepare(mockCorpus); err != nil {
+		f.tb.Fatal(err)
+	}
+
+	return m
+}
+
+func (f *fixture) train() *Model {
+	f.tb.Helper()
+
+	tr := NewTrainer(f.opts)
+
+	tr.SetOutput(io.Discard)
+
+	m, err := tr.Train()
+
+	if err != nil {
+		f.tb.Fatal(err)
+	}
+
+	return m
+}
+
+// count returns a count holding the given values.
+func (f *fixture) count(values map[string]map[string]float64) *Count {
+	c := NewCount()
+
+	for feature, keys := range values {
+		for key, val := range keys {
+			c.Add(feature, key, big.NewFloat(val))
+		}
+	}
+
+	return c
+}
+
+// graphs returns the graphs of the positive samples under m.
+func (f *fixture) graphs(m *Model) ([]*Graph, []*Sample) {
+	f.tb.Helper()
+
+	corpus, err := NewIterator(mockCorpus, f.opts.CorpusFormat)
+
+	if err != nil {
+		f.tb.Fatal(err)
+	}
+
+	defer corpus.Close()
+
+	graphs := make([]*Graph, 0)
+	samples := make([]*Sample, 0)
+
+	for corpus.Next() {
+		sample := corpus.Sample()
+
+		if !sample.Positive() {
+			continue
+		}
+
+		mt, e, err := m.InitSample(sample)
+
+		if err != nil {
+			f.tb.Fatal(err)
+		}
+
+		g, err := NewGraph(mt, e, m)
+
+		if err != nil {
+			f.tb.Fatal(err)
+		}
+
+		graphs = append(graphs, g)
+		samples = append(samples, sample)
+	}
+
+	return graphs, samples
+}
+
+// counts returns empty insertion, reordering, translation, lambda and
+// fertility counts for the arithmetic backend of the fixture.
+func (f *fixture) counts() [5]*Count {
+	newCount := NewCount
+
+	if f.opts.LogSpaceArithmetic {
+		newCount = NewLogCount
+	}
+
+	return [5]*Count{newCount(), newCount(), newCount(), newCount(), newCount()}
+}
+
+// collect adds the expected counts of the graphs of m to a new set of counts.
+func (f *fixture) collect(m *Model, collect func(*Graph, float64, [5]*Count)) [5]*Count {
+	counts := f.counts()
+
+	graphs, samples := f.graphs(m)
+
+	for i, g := range graphs {
+		collect(g, samples[i].Weight, counts)
+	}
+
+	return counts
+}
+
+// writeCorpus writes a corpus file to a temporary directory and returns its
+// path.
+func writeCorpus(tb testing.TB, name, content string) string {
+	tb.Helper()
+
+	path := filepath.Join(tb.TempDir(), name)
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		tb.Fatal(err)
+	}
+
+	return path
+}
+
+// readCorpus returns all samples of a corpus and the error that ended it.
+func readCorpus(tb testing.TB, name, format string) ([]*Sample, error) {
+	tb.Helper()
+
+	it, err := NewIterator(name, format)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer it.Close()
+
+	samples := make([]*Sample, 0)
+
+	for it.Next() {
+		samples = append(samples, it.Sample())
+	}
+
+	if err := it.Error(); err != io.EOF {
+		return samples, err
+	}
+
+	return samples, nil
+}
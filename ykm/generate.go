@@ -0,0 +1,315 @@
+package ykm
+
+import (
+	"github.com/jonasknobloch/jinn/pkg/tree"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type hypothesis struct {
+	tokens []string
+	score  float64
+}
+
+type beam []hypothesis
+
+func (b beam) Prune(width int) beam {
+	index := make(map[string]int, len(b))
+	merged := make(beam, 0, len(b))
+
+	for _, h := range b {
+		if math.IsInf(h.score, -1) {
+			continue
+		}
+
+		key := strings.Join(h.tokens, " ")
+
+		if i, ok := index[key]; ok {
+			merged[i].score = logAdd(merged[i].score, h.score)
+			continue
+		}
+
+		index[key] = len(merged)
+		merged = append(merged, h)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].score > merged[j].score
+	})
+
+	if len(merged) > width {
+		merged = merged[:width]
+	}
+
+	return merged
+}
+
+type Candidate struct {
+	Sentence         string  `json:"sentence"`
+	LogProbability   float64 `json:"log_probability"`
+	LMLogProbability float64 `json:"lm_log_probability,omitempty"`
+	Score            float64 `json:"score"`
+}
+
+type Generation struct {
+	Tree       string      `json:"tree"`
+	Candidates []Candidate `json:"candidates"`
+}
+
+type Generator struct {
+	model  *Model
+	lm     *LanguageModel
+	weight float64
+	width  int
+}
+
+func NewGenerator(m *Model, lm *LanguageModel, weight float64, width int) *Generator {
+	return &Generator{
+		model:  m,
+		lm:     lm,
+		weight: weight,
+		width:  width,
+	}
+}
+
+func (gen *Generator) keys(table map[string]map[string]*big.Float, feature Features) []string {
+	seen := make(map[string]bool)
+	keys := make([]string, 0)
+
+	for _, f := range feature.Lookup() {
+		for key := range table[f] {
+			if seen[key] || key == BackoffKey {
+				continue
+			}
+
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (gen *Generator) tokens(feature Features) beam {
+	b := make(beam, 0)
+
+	for _, key := range gen.keys(gen.model.t, feature) {
+		if key == NullToken || strings.Contains(key, UnknownToken) || strings.Contains(key, " ") {
+			continue
+		}
+
+		p := gen.model.Probability(NewTranslation(key, feature))
+
+		b = append(b, hypothesis{tokens: []string{key}, score: LogOf(p)})
+	}
+
+	return b.Prune(gen.width)
+}
+
+func (gen *Generator) translations(feature Features) beam {
+	phrases := make(map[string]bool)
+
+	if gen.model.opts.EnablePhrasalTranslations && gen.model.opts.EnableFertilityDecomposition {
+		tokens := gen.tokens(feature)
+
+		for _, key := range gen.keys(gen.model.f, feature) {
+			k, err := strconv.Atoi(key)
+
+			if err != nil {
+				continue
+			}
+
+			if k == 0 {
+				phrases[NullToken] = true
+				continue
+			}
+
+			partial := beam{hypothesis{tokens: []string{}}}
+
+			for i := 0; i < k; i++ {
+				next := make(beam, 0, len(partial)*len(tokens))
+
+				for _, h := range partial {
+					for _, t := range tokens {
+						next = append(next, hypothesis{
+							tokens: append(append([]string{}, h.tokens...), t.tokens...),
+							score:  h.score + t.score,
+						})
+					}
+				}
+
+				partial = next.Prune(gen.width)
+			}
+
+			for _, h := range partial {
+				phrases[strings.Join(h.tokens, " ")] = true
+			}
+		}
+	} else {
+		for _, key := range gen.keys(gen.model.t, feature) {
+			if strings.Contains(key, UnknownToken) {
+				continue
+			}
+
+			phrases[key] = true
+		}
+	}
+
+	b := make(beam, 0, len(phrases))
+
+	for phrase := range phrases {
+		p := gen.model.LogProbability(NewTranslation(phrase, feature))
+
+		tokens := make([]string, 0)
+
+		if phrase != NullToken {
+			tokens = strings.Split(phrase, " ")
+		}
+
+		b = append(b, hypothesis{tokens: tokens, score: p})
+	}
+
+	return b.Prune(gen.width)
+}
+
+func (gen *Generator) insertions(st *tree.Tree, feature Features) []Insertion {
+	ops := []Insertion{NewInsertion(None, "", feature)}
+
+	if !gen.model.opts.EnableInteriorInsertions && len(st.Children) != 0 {
+		return ops
+	}
+
+	if !gen.model.opts.EnableTerminalInsertions && len(st.Children) == 0 {
+		return ops
+	}
+
+	for _, key := range gen.keys(gen.model.n, feature) {
+		pos, word := InsertPosition(key[:1]), ""
+
+		if len(key) > 2 {
+			word = key[2:]
+		}
+
+		if pos == None || word == "" || word == UnknownToken {
+			continue
+		}
+
+		ops = append(ops, NewInsertion(pos, word, feature))
+	}
+
+	return ops
+}
+
+func (gen *Generator) expand(st *tree.Tree, mt *MetaTree) beam {
+	inner := make(beam, 0)
+
+	if len(st.Children) == 0 {
+		inner = gen.translations(mt.Feature(st, TranslationFeature))
+	} else {
+		lambda, kappa := gen.model.Lambda(tFeature(st, false))
+
+		if gen.model.opts.EnablePhrasalTranslations {
+			for _, h := range gen.translations(mt.Feature(st, TranslationFeature)) {
+				h.score += LogOf(lambda)
+				inner = append(inner, h)
+			}
+		}
+
+		children := make([]beam, len(st.Children))
+
+		for i, c := range st.Children {
+			children[i] = gen.expand(c, mt)
+		}
+
+		for _, op := range Reorderings(st, mt.Feature(st, ReorderingFeature)) {
+			reordering := op.(Reordering)
+
+			partial := beam{hypothesis{tokens: []string{}, score: gen.model.LogProbability(reordering) + LogOf(kappa)}}
+
+			for _, c := range reordering.Reordering {
+				next := make(beam, 0, len(partial)*len(children[c]))
+
+				for _, h := range partial {
+					for _, ch := range children[c] {
+						next = append(next, hypothesis{
+							tokens: append(append([]string{}, h.tokens...), ch.tokens...),
+							score:  h.score + ch.score,
+						})
+					}
+				}
+
+				partial = next.Prune(gen.width)
+			}
+
+			inner = append(inner, partial...)
+		}
+
+		inner = inner.Prune(gen.width)
+	}
+
+	outer := make(beam, 0, len(inner))
+
+	for _, insertion := range gen.insertions(st, mt.Feature(st, InsertionFeature)) {
+		p := gen.model.LogProbability(insertion)
+
+		for _, h := range inner {
+			tokens := make([]string, 0, len(h.tokens)+1)
+
+			if insertion.Position == Left {
+				tokens = append(tokens, insertion.Word)
+			}
+
+			tokens = append(tokens, h.tokens...)
+
+			if insertion.Position == Right {
+				tokens = append(tokens, insertion.Word)
+			}
+
+			outer = append(outer, hypothesis{tokens: tokens, score: h.score + p})
+		}
+	}
+
+	return outer.Prune(gen.width)
+}
+
+func (gen *Generator) Generate(t *tree.Tree, k int) []Candidate {
+	if gen.model.opts.ReplaceSparseTokens && gen.model.vocabulary != nil {
+		replaceSparseLabels(t.Leaves(), gen.model.vocabulary, gen.model.opts.SparseTokenThreshold)
+	}
+
+	mt := NewMetaTree(t)
+
+	mt.CollectFeatures(gen.model.opts)
+
+	candidates := make([]Candidate, 0, k)
+
+	for _, h := range gen.expand(t, mt) {
+		c := Candidate{
+			Sentence:       strings.Join(h.tokens, " "),
+			LogProbability: h.score,
+			Score:          h.score,
+		}
+
+		if gen.lm != nil {
+			c.LMLogProbability = gen.lm.Score(h.tokens)
+			c.Score += gen.weight * c.LMLogProbability
+		}
+
+		candidates = append(candidates, c)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+
+	return candidates
+}
@@ -0,0 +1,113 @@
+package ykm
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+const mockARPA = `\data\
+ngram 1=4
+ngram 2=2
+
+\1-grams:
+-1.0	<s>	-0.5
+-0.5	a	-0.3
+-0.7	b
+-1.0	</s>
+
+\2-grams:
+-0.2	<s> a
+-0.1	a b
+
+\end\
+`
+
+func TestLanguageModel(t *testing.T) {
+	lm, err := LoadARPA(writeCorpus(t, "mock.arpa", mockARPA))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		tokens []string
+		want   float64
+	}{
+		{[]string{"a", "b"}, -0.2 - 0.1 - 1.0},
+		// backs off from <s> and scores the unknown word with the floor
+		{[]string{"b", "zzz"}, -0.5 - 0.7 - 99 - 1.0},
+	}
+
+	for _, test := range tests {
+		if got := lm.Score(test.tokens); math.Abs(got-test.want*math.Ln10) > 1e-9 {
+			t.Errorf("score of %v = %v, want %v", test.tokens, got, test.want*math.Ln10)
+		}
+	}
+
+	if _, err := LoadARPA(writeCorpus(t, "empty.arpa", "\\data\\\n\\end\\\n")); err == nil {
+		t.Error("expected an error for a language model without n-grams")
+	}
+}
+
+func TestBeamPrune(t *testing.T) {
+	b := beam{
+		{tokens: []string{"a"}, score: math.Log(0.25)},
+		{tokens: []string{"b"}, score: math.Log(0.375)},
+		{tokens: []string{"a"}, score: math.Log(0.25)},
+		{tokens: []string{"c"}, score: math.Inf(-1)},
+	}.Prune(2)
+
+	if len(b) != 2 || b[0].tokens[0] != "a" || b[1].tokens[0] != "b" {
+		t.Fatalf("got beam %v, want a b", b)
+	}
+
+	if math.Abs(b[0].score-math.Log(0.5)) > 1e-12 {
+		t.Errorf("merged score %v, want %v", b[0].score, math.Log(0.5))
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	f := newFixture(t, nil)
+
+	m := f.train()
+
+	lm, err := LoadARPA(writeCorpus(t, "mock.arpa", mockARPA))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	known := map[string]bool{"s": true, "b": true, "a": true, "α": true, "β": true}
+
+	for _, weight := range []float64{0, 0.5} {
+		st, err := DecodeTree("(σ (γ α) (γ β))", FormatAuto)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		candidates := NewGenerator(m, lm, weight, 100).Generate(st, 10)
+
+		if len(candidates) == 0 || len(candidates) > 10 {
+			t.Fatalf("got %d candidates", len(candidates))
+		}
+
+		for i, c := range candidates {
+			if i > 0 && c.Score > candidates[i-1].Score {
+				t.Errorf("candidate %q is ranked below a worse one", c.Sentence)
+			}
+
+			if c.LogProbability > 0 || math.Abs(c.Score-c.LogProbability-weight*c.LMLogProbability) > 1e-9 {
+				t.Errorf("candidate %+v has an inconsistent score", c)
+			}
+
+			// the model only translates into words of the training targets
+			for _, token := range strings.Fields(c.Sentence) {
+				if !known[token] {
+					t.Errorf("candidate %q contains the unseen word %s", c.Sentence, token)
+				}
+			}
+		}
+	}
+}
@@ -1,10 +1,9 @@
-package main
+package ykm
 
 import (
 	"errors"
 	"github.com/jonasknobloch/jinn/pkg/tree"
 	"math/big"
-	"strings"
 )
 
 type Graph struct {
@@ -16,15 +15,34 @@ type Graph struct {
 	pAlpha map[*Node]*big.Float
 	pBeta  map[*Node]*big.Float
 
+	opts Options
+
+	logSpace bool
+	logEdges map[[2]*Node]float64
+	lAlpha   map[*Node]float64
+	lBeta    map[*Node]float64
+
+	lPartition map[*Node]float64
+
 	insertions   map[string]map[string][]*Node
 	reorderings  map[string]map[string][]*Node
 	translations map[string]map[string][]*Node
 
 	lambda map[string]map[string][]*Node
 
+	// tracked holds the operations already tracked for a node if counts
+	// are corrected
+	tracked map[trackedOperation]struct{}
+
 	major map[*tree.Tree]map[string]*Node
 }
 
+type trackedOperation struct {
+	table string
+	key   string
+	n     *Node
+}
+
 const LambdaKey = "l"
 const KappaKey = "k"
 
@@ -46,12 +64,23 @@ func NewGraph(mt *MetaTree, f []string, m *Model) (*Graph, error) {
 		pAlpha: make(map[*Node]*big.Float),
 		pBeta:  make(map[*Node]*big.Float),
 
+		opts: m.opts,
+
+		logSpace: m.opts.LogSpaceArithmetic,
+		logEdges: make(map[[2]*Node]float64),
+		lAlpha:   make(map[*Node]float64),
+		lBeta:    make(map[*Node]float64),
+
+		lPartition: make(map[*Node]float64),
+
 		insertions:   make(map[string]map[string][]*Node),
 		reorderings:  make(map[string]map[string][]*Node),
 		translations: make(map[string]map[string][]*Node),
 
 		lambda: make(map[string]map[string][]*Node),
 
+		tracked: make(map[trackedOperation]struct{}),
+
 		major: make(map[*tree.Tree]map[string]*Node),
 	}
 
@@ -62,35 +91,59 @@ func NewGraph(mt *MetaTree, f []string, m *Model) (*Graph, error) {
 		return g, errors.New("invalid root node")
 	}
 
-	if Config.EnablePhrasalTranslations {
+	if g.opts.EnablePhrasalTranslations {
 		g.InvalidateUnreachableNodes(mt.Tree)
 	}
 
-	g.Beta(n)
+	if g.logSpace {
+		g.LogBeta(n)
+	} else {
+		g.Beta(n)
+	}
 
 	for _, node := range g.nodes {
 		if node.nType != MajorNode {
 			continue
 		}
 
-		if Config.EnablePhrasalTranslations && !node.valid {
+		if g.opts.EnablePhrasalTranslations && !node.valid {
 			continue
 		}
 
-		if !Config.EnablePhrasalTranslations && !node.valid {
+		if !g.opts.EnablePhrasalTranslations && !node.valid {
 			panic("unexpected invalid node")
 		}
 
-		g.Alpha(node)
+		if g.logSpace {
+			g.LogAlpha(node)
+		} else {
+			g.Alpha(node)
+		}
 	}
 
-	if len(g.pBeta) != len(g.pAlpha) {
+	if len(g.pBeta) != len(g.pAlpha) || len(g.lBeta) != len(g.lAlpha) {
 		panic("beta and alpha map lengths do not match")
 	}
 
 	return g, nil
 }
 
+func (g *Graph) Probability() *big.Float {
+	if g.logSpace {
+		return ExpOf(g.lBeta[g.nodes[0]])
+	}
+
+	return g.pBeta[g.nodes[0]]
+}
+
+func (g *Graph) LogProbability() float64 {
+	if g.logSpace {
+		return g.lBeta[g.nodes[0]]
+	}
+
+	return LogOf(g.pBeta[g.nodes[0]])
+}
+
 func (g *Graph) AddNode(n *Node) {
 	g.nodes = append(g.nodes, n)
 }
@@ -98,6 +151,24 @@ func (g *Graph) AddNode(n *Node) {
 func (g *Graph) AddEdge(n1, n2 *Node, w *big.Float) {
 	g.edges[[2]*Node{n1, n2}] = w
 
+	g.link(n1, n2)
+}
+
+func (g *Graph) AddLogEdge(n1, n2 *Node, w float64) {
+	g.logEdges[[2]*Node{n1, n2}] = w
+
+	g.link(n1, n2)
+}
+
+func (g *Graph) LogEdge(n1, n2 *Node) float64 {
+	if g.logSpace {
+		return g.logEdges[[2]*Node{n1, n2}]
+	}
+
+	return LogOf(g.edges[[2]*Node{n1, n2}])
+}
+
+func (g *Graph) link(n1, n2 *Node) {
 	if _, ok := g.pred[n2]; !ok {
 		if n2.nType == MajorNode {
 			g.pred[n2] = make([]*Node, 0)
@@ -173,9 +244,21 @@ func (g *Graph) AddOperation(op Operation, n *Node) {
 		panic("unexpected operation type")
 	}
 
-	feature, key := op.Feature(), op.Key()
+	// a node reaches the same operation through several insertions, e.g. a
+	// reordering below each of its none, left and right insertions
+	if g.opts.EnableCountCorrection {
+		id := trackedOperation{tableName(op), op.Key(), n}
 
-	g.TrackNode(m, feature, key, n)
+		if _, ok := g.tracked[id]; ok {
+			return
+		}
+
+		g.tracked[id] = struct{}{}
+	}
+
+	for _, feature := range op.Backoff() {
+		g.TrackNode(m, feature, op.Key(), n)
+	}
 }
 
 func partitioning(reordering *Node, mt *MetaTree) [][]int {
@@ -210,20 +293,31 @@ func partitioning(reordering *Node, mt *MetaTree) [][]int {
 }
 
 func (g *Graph) Expand(n *Node, m *Model, mt *MetaTree) {
-	e := func() []string {
-		leaves := n.tree.Leaves()
-		labels := make([]string, len(leaves))
+	eStr := n.Yield()
+
+	edge := func(n1, n2 *Node, op Operation) {
+		if g.logSpace {
+			w := 0.0
 
-		for i, leaf := range leaves {
-			labels[i] = leaf.Label
+			if op != nil {
+				w = m.LogProbability(op)
+			}
+
+			g.AddLogEdge(n1, n2, w)
+
+			return
 		}
 
-		return labels
-	}()
+		w := big.NewFloat(1)
 
-	eStr := strings.Join(e, " ")
+		if op != nil {
+			w = m.Probability(op)
+		}
+
+		g.AddEdge(n1, n2, w)
+	}
 
-	for _, op := range Insertions(n.tree, n.f[n.k:n.k+n.l], mt.MaxFertility(n.tree), mt.Feature(n.tree, InsertionFeature)) {
+	for _, op := range Insertions(n.tree, n.f[n.k:n.k+n.l], mt.MaxFertility(n.tree), mt.Feature(n.tree, InsertionFeature), g.opts) {
 		insertion := op.(Insertion)
 
 		k := n.k
@@ -247,11 +341,11 @@ func (g *Graph) Expand(n *Node, m *Model, mt *MetaTree) {
 			nType: SubNode,
 		}
 
-		phrasal := len(n.tree.Children) != 0 && Config.EnablePhrasalTranslations
+		phrasal := len(n.tree.Children) != 0 && g.opts.EnablePhrasalTranslations
 
-		if phrasal && phrasalFrequencies != nil {
-			frequency, ok := phrasalFrequencies[eStr][i.Substring()]
-			phrasal = ok && frequency >= Config.PhraseFrequencyCutoff
+		if phrasal && m.phrasal != nil {
+			frequency, ok := m.phrasal[eStr][i.Substring()]
+			phrasal = ok && frequency >= g.opts.PhraseFrequencyCutoff
 		}
 
 		if (len(n.tree.Children) == 0 && i.l < 2) || phrasal {
@@ -272,7 +366,7 @@ func (g *Graph) Expand(n *Node, m *Model, mt *MetaTree) {
 			n.valid = true
 
 			g.AddNode(f)
-			g.AddEdge(i, f, m.Probability(translation))
+			edge(i, f, translation)
 			g.AddOperation(translation, n)
 		}
 
@@ -332,11 +426,11 @@ func (g *Graph) Expand(n *Node, m *Model, mt *MetaTree) {
 
 					p.valid = p.valid && g.major[c][sub].valid
 
-					g.AddEdge(p, g.major[c][sub], big.NewFloat(1))
+					edge(p, g.major[c][sub], nil)
 				}
 
 				g.AddNode(p)
-				g.AddEdge(r, p, big.NewFloat(1))
+				edge(r, p, nil)
 
 				r.valid = r.valid || p.valid
 				i.valid = i.valid || r.valid
@@ -344,17 +438,18 @@ func (g *Graph) Expand(n *Node, m *Model, mt *MetaTree) {
 			}
 
 			g.AddNode(r)
-			g.AddEdge(i, r, m.Probability(reordering))
+			edge(i, r, reordering)
 			g.AddOperation(reordering, n)
 		}
 
 		g.AddNode(i)
-		g.AddEdge(n, i, m.Probability(insertion))
+		edge(n, i, insertion)
 		g.AddOperation(insertion, n)
 	}
 
 	if len(n.tree.Children) != 0 {
 		n.lambda, n.kappa = m.Lambda(eStr)
+		n.logLambda, n.logKappa = LogOf(n.lambda), LogOf(n.kappa)
 
 		g.TrackNode(g.lambda, eStr, LambdaKey, n)
 		g.TrackNode(g.lambda, eStr, KappaKey, n)
@@ -439,3 +534,73 @@ func (g *Graph) Beta(n *Node) *big.Float {
 
 	return g.pBeta[n]
 }
+
+func (g *Graph) LogAlpha(n *Node) float64 {
+	if a, ok := g.lAlpha[n]; ok {
+		return a
+	}
+
+	if n == g.nodes[0] {
+		g.lAlpha[n] = 0
+
+		return g.lAlpha[n]
+	}
+
+	sum := logZero
+
+	for _, partition := range g.pred[n] {
+		if !partition.valid {
+			continue
+		}
+
+		reordering := g.pred[partition][0]
+		insertion := g.pred[reordering][0]
+		major := g.pred[insertion][0]
+
+		prod := g.LogAlpha(major) + g.logEdges[[2]*Node{major, insertion}]
+
+		rProb := g.logEdges[[2]*Node{insertion, reordering}]
+		tProb := logZero
+
+		for _, translation := range g.succ[insertion] {
+			if !translation.valid {
+				continue
+			}
+
+			if translation.nType == FinalNode {
+				tProb = g.logEdges[[2]*Node{insertion, translation}]
+				break
+			}
+		}
+
+		prod += logAdd(rProb+major.logKappa, tProb+major.logLambda)
+
+		for _, sibling := range g.succ[partition] {
+			if !sibling.valid {
+				continue
+			}
+
+			if sibling == n {
+				continue
+			}
+
+			prod += g.LogBeta(sibling)
+		}
+
+		sum = logAdd(sum, prod)
+	}
+
+	g.lAlpha[n] = sum
+
+	return g.lAlpha[n]
+}
+
+func (g *Graph) LogBeta(n *Node) float64 {
+	if b, ok := g.lBeta[n]; ok {
+		return b
+	}
+
+	g.lBeta[n] = g.LogInsideWeight(n, [3]string{}, nil, nil)
+
+	return g.lBeta[n]
+}
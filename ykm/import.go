@@ -0,0 +1,335 @@
+package ykm
+
+import (
+	"bufio"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func Import(name string) (map[string]map[string]*big.Float, error) {
+	file, err := os.Open(name)
+
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+
+	defer file.Close()
+
+	t := make(map[string]map[string]*big.Float)
+
+	dec := gob.NewDecoder(file)
+
+	if err := dec.Decode(&t); err != nil {
+		return nil, fmt.Errorf("error decoding file: %w", err)
+	}
+
+	return t, nil
+}
+
+func ImportModel(name string) (*Model, error) {
+	file, err := os.Open(name)
+
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+
+	defer file.Close()
+
+	var mf modelFile
+
+	dec := gob.NewDecoder(file)
+
+	if err := dec.Decode(&mf); err != nil {
+		return nil, fmt.Errorf("error decoding file: %w", err)
+	}
+
+	if mf.Info.Version > ModelFormatVersion {
+		return nil, fmt.Errorf("unsupported model format version: %d", mf.Info.Version)
+	}
+
+	m := NewModel(Options{})
+
+	if err := mf.restore(m); err != nil {
+		return nil, fmt.Errorf("error decoding file: %w", err)
+	}
+
+	return m, nil
+}
+
+func ImportTSV(name string) (*Model, error) {
+	file, err := os.Open(name)
+
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+
+	defer file.Close()
+
+	m := NewModel(Options{})
+	tables := m.Tables()
+
+	scanner := bufio.NewScanner(file)
+
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	line := 0
+	header := true
+
+	for scanner.Scan() {
+		line++
+
+		if info := scanner.Text(); header && strings.HasPrefix(info, tsvInfoPrefix) {
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(info, tsvInfoPrefix)), &m.info); err != nil {
+				return nil, fmt.Errorf("line %d: invalid model info: %w", line, err)
+			}
+
+			if m.info.Version > ModelFormatVersion {
+				return nil, fmt.Errorf("unsupported model format version: %d", m.info.Version)
+			}
+
+			continue
+		}
+
+		fields := strings.Split(scanner.Text(), "\t")
+
+		if header && strings.Join(fields, " ") == "table feature key probability" {
+			header = false
+
+			continue
+		}
+
+		header = false
+
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("line %d: expected 4 columns but got %d", line, len(fields))
+		}
+
+		t, ok := tables[fields[0]]
+
+		if !ok {
+			return nil, fmt.Errorf("line %d: unknown table: %s", line, fields[0])
+		}
+
+		p, _, err := big.ParseFloat(fields[3], 10, 53, big.ToNearestEven)
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid probability: %w", line, err)
+		}
+
+		if _, ok := t[fields[1]]; !ok {
+			t[fields[1]] = make(map[string]*big.Float)
+		}
+
+		t[fields[1]][fields[2]] = p
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+
+	return m, nil
+}
+
+func ImportJSON(name string) (*Model, error) {
+	file, err := os.Open(name)
+
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+
+	defer file.Close()
+
+	var jm jsonModel
+
+	if err := json.NewDecoder(file).Decode(&jm); err != nil {
+		return nil, fmt.Errorf("error decoding file: %w", err)
+	}
+
+	if jm.Info.Version > ModelFormatVersion {
+		return nil, fmt.Errorf("unsupported model format version: %d", jm.Info.Version)
+	}
+
+	m := NewModel(Options{})
+	tables := m.Tables()
+
+	m.info = jm.Info
+
+	for name, features := range jm.Tables {
+		t, ok := tables[name]
+
+		if !ok {
+			return nil, fmt.Errorf("unknown table: %s", name)
+		}
+
+		for feature, keys := range features {
+			t[feature] = make(map[string]*big.Float, len(keys))
+
+			for key, val := range keys {
+				p, _, err := big.ParseFloat(val, 10, 53, big.ToNearestEven)
+
+				if err != nil {
+					return nil, fmt.Errorf("invalid probability for %s [%s : %s]: %w", name, feature, key, err)
+				}
+
+				t[feature][key] = p
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func importLegacyModel(name string) (*Model, error) {
+	n, err := Import(name + "-n.gob")
+
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := Import(name + "-r.gob")
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := Import(name + "-t.gob")
+
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := Import(name + "-l.gob")
+
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := Import(name + "-f.gob")
+
+	if err != nil {
+		return nil, err
+	}
+
+	m := NewModel(Options{})
+
+	m.n, m.r, m.t, m.l, m.f = n, r, t, l, f
+
+	return m, nil
+}
+
+func LoadModel(name string, opts Options) (*Model, error) {
+	exists := func(name string) bool {
+		_, err := os.Stat(name)
+		return err == nil
+	}
+
+	var m *Model
+	var err error
+
+	warnings := make([]string, 0)
+
+	load := func(name string) (*Model, error) {
+		switch filepath.Ext(name) {
+		case "." + FormatTSV:
+			return ImportTSV(name)
+		case "." + FormatJSON:
+			return ImportJSON(name)
+		default:
+			return ImportModel(name)
+		}
+	}
+
+	switch {
+	case exists(name):
+		m, err = load(name)
+	case exists(name + "." + FormatGob):
+		m, err = load(name + "." + FormatGob)
+	case exists(name + "." + FormatTSV):
+		m, err = load(name + "." + FormatTSV)
+	case exists(name + "." + FormatJSON):
+		m, err = load(name + "." + FormatJSON)
+	case exists(name + "-n.gob"):
+		warnings = append(warnings, fmt.Sprintf("importing legacy model %s, run convert to convert it", name))
+		m, err = importLegacyModel(name)
+	default:
+		return nil, errors.New("model not found: " + name)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	incompatible, err := checkCompatibility(m.info, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	m.opts = opts
+	m.warnings = append(warnings, incompatible...)
+	m.vocabulary = m.info.Vocabulary
+	m.lengths = m.info.Lengths
+
+	return m, nil
+}
+
+// checkCompatibility compares opts with the config a model was trained with
+// and returns a warning for every setting that differs. Legacy and converted
+// models carry no config and are not checked.
+func checkCompatibility(info ModelInfo, opts Options) ([]string, error) {
+	if info.Version == 0 || info.Converted {
+		return nil, nil
+	}
+
+	trained := info.Config
+
+	if opts.EnablePhrasalTranslations && !trained.EnablePhrasalTranslations {
+		return nil, errors.New("phrasal translations enabled but model was trained without them")
+	}
+
+	warnings := make([]string, 0)
+
+	warn := func(name string, current, trained interface{}) {
+		warnings = append(warnings, fmt.Sprintf("%s is %v but model was trained with %v", name, current, trained))
+	}
+
+	if opts.EnablePhrasalTranslations != trained.EnablePhrasalTranslations {
+		warn("ENABLE_PHRASAL_TRANSLATIONS", opts.EnablePhrasalTranslations, trained.EnablePhrasalTranslations)
+	}
+
+	if opts.EnableFertilityDecomposition != trained.EnableFertilityDecomposition {
+		warn("ENABLE_FERTILITY_DECOMPOSITION", opts.EnableFertilityDecomposition, trained.EnableFertilityDecomposition)
+	}
+
+	if opts.EnableInteriorInsertions != trained.EnableInteriorInsertions {
+		warn("ENABLE_INTERIOR_INSERTIONS", opts.EnableInteriorInsertions, trained.EnableInteriorInsertions)
+	}
+
+	if opts.EnableTerminalInsertions != trained.EnableTerminalInsertions {
+		warn("ENABLE_TERMINAL_INSERTIONS", opts.EnableTerminalInsertions, trained.EnableTerminalInsertions)
+	}
+
+	if opts.ReplaceSparseTokens != trained.ReplaceSparseTokens {
+		warn("REPLACE_SPARSE_TOKENS", opts.ReplaceSparseTokens, trained.ReplaceSparseTokens)
+	}
+
+	if opts.ReplaceSparseTokens && opts.SparseTokenThreshold != trained.SparseTokenThreshold {
+		warn("SPARSE_TOKEN_THRESHOLD", opts.SparseTokenThreshold, trained.SparseTokenThreshold)
+	}
+
+	if opts.EnablePhrasalTranslations && opts.PhraseLengthLimit != trained.PhraseLengthLimit {
+		warn("PHRASE_LENGTH_LIMIT", opts.PhraseLengthLimit, trained.PhraseLengthLimit)
+	}
+
+	if opts.EnablePhrasalTranslations && opts.MaxPhraseLengthDifference != trained.MaxPhraseLengthDifference {
+		warn("MAX_PHRASE_LENGTH_DIFFERENCE", opts.MaxPhraseLengthDifference, trained.MaxPhraseLengthDifference)
+	}
+
+	return warnings, nil
+}
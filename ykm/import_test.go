@@ -0,0 +1,144 @@
+package ykm
+
+import (
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeGob(t *testing.T, name string, v interface{}) {
+	t.Helper()
+
+	f, err := os.Create(name)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	if err := gob.NewEncoder(f).Encode(v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tablesOnly returns a model that shares the tables of m but nothing else.
+func tablesOnly(m *Model) *Model {
+	c := NewModel(Options{})
+
+	c.n, c.r, c.t, c.l, c.f = m.n, m.r, m.t, m.l, m.f
+
+	return c
+}
+
+func TestImportLegacyModel(t *testing.T) {
+	f := newFixture(t, nil)
+
+	m := f.train()
+
+	name := filepath.Join(t.TempDir(), "model_1")
+
+	for table, values := range m.Tables() {
+		writeGob(t, name+"-"+table+".gob", values)
+	}
+
+	imported, err := LoadModel(name, f.opts)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w := imported.Warnings(); len(w) != 1 || !strings.Contains(w[0], "legacy model") {
+		t.Errorf("got warnings %v, want a legacy model warning", w)
+	}
+
+	want, _ := Checksum(tablesOnly(m))
+
+	if got, _ := Checksum(imported); got != want {
+		t.Errorf("legacy model has checksum %s, want %s", got, want)
+	}
+}
+
+func TestImportModelVersions(t *testing.T) {
+	f := newFixture(t, nil)
+
+	m := f.train()
+
+	dir := t.TempDir()
+
+	// version 1 files store the tables in maps
+	v1 := modelFile{Info: ModelInfo{Version: 1}, N: m.n, R: m.r, T: m.t, L: m.l, F: m.f}
+
+	writeGob(t, filepath.Join(dir, "v1.gob"), v1)
+
+	imported, err := LoadModel(filepath.Join(dir, "v1.gob"), f.opts)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, _ := Checksum(tablesOnly(m))
+
+	if got, _ := Checksum(imported); got != want {
+		t.Errorf("version 1 model has checksum %s, want %s", got, want)
+	}
+
+	future := newModelFile(m)
+
+	future.Info.Version = ModelFormatVersion + 1
+
+	writeGob(t, filepath.Join(dir, "future.gob"), future)
+
+	if _, err := LoadModel(filepath.Join(dir, "future.gob"), f.opts); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("got error %v, want an unsupported version", err)
+	}
+
+	if _, err := LoadModel(filepath.Join(dir, "missing"), f.opts); err == nil {
+		t.Error("expected an error for a missing model")
+	}
+}
+
+func TestImportText(t *testing.T) {
+	header := "table\tfeature\tkey\tprobability\n"
+
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"plain.tsv", header + "t\tα\ta\t0.5\nt\tα\tb\t0.5\n", ""},
+		{"headless.tsv", "t\tα\ta\t0.5\n", ""},
+		{"columns.tsv", header + "t\tα\ta\n", "expected 4 columns"},
+		{"table.tsv", header + "x\tα\ta\t1\n", "unknown table"},
+		{"probability.tsv", header + "t\tα\ta\tp\n", "invalid probability"},
+		{"version.tsv", `# {"Version": 99}` + "\n" + header, "unsupported model format version"},
+		{"plain.json", `{"info": {"Version": 2}, "tables": {"t": {"α": {"a": "0.5", "b": "0.5"}}}}`, ""},
+		{"table.json", `{"tables": {"x": {"α": {"a": "1"}}}}`, "unknown table"},
+		{"probability.json", `{"tables": {"t": {"α": {"a": "p"}}}}`, "invalid probability"},
+		{"version.json", `{"info": {"Version": 99}}`, "unsupported model format version"},
+	}
+
+	for _, test := range tests {
+		m, err := LoadModel(writeCorpus(t, test.name, test.content), Options{})
+
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+
+			continue
+		}
+
+		if p := m.t["α"]["a"]; p == nil || p.String() != "0.5" {
+			t.Errorf("%s: got translation probability %v", test.name, p)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package ykm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type jsonSample struct {
+	ID       string                 `json:"id"`
+	Tree     string                 `json:"tree"`
+	Sentence string                 `json:"sentence"`
+	Label    json.RawMessage        `json:"label"`
+	Weight   *float64               `json:"weight"`
+	Meta     map[string]interface{} `json:"meta"`
+}
+
+func parseLabel(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+
+	var val interface{}
+
+	if err := json.Unmarshal(raw, &val); err != nil {
+		return "", err
+	}
+
+	switch v := val.(type) {
+	case bool:
+		if v {
+			return "1", nil
+		}
+
+		return "0", nil
+	case float64:
+		if v == 0 || v == 1 {
+			return strconv.Itoa(int(v)), nil
+		}
+	case string:
+		if v == "0" || v == "1" {
+			return v, nil
+		}
+	}
+
+	return "", fmt.Errorf("invalid label: %s", raw)
+}
+
+func jsonlReader(r io.Reader) func() (*Sample, error) {
+	scanner := bufio.NewScanner(r)
+
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	line := 0
+
+	return func() (*Sample, error) {
+		for scanner.Scan() {
+			line++
+
+			data := bytes.TrimSpace(scanner.Bytes())
+
+			if len(data) == 0 {
+				continue
+			}
+
+			var js jsonSample
+
+			if err := json.Unmarshal(data, &js); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+
+			if js.Tree == "" || js.Sentence == "" {
+				return nil, fmt.Errorf("line %d: missing tree or sentence", line)
+			}
+
+			label, err := parseLabel(js.Label)
+
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+
+			sample, err := NewSample(js.ID, js.Tree, js.Sentence, label)
+
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+
+			if js.Weight != nil {
+				if !validWeight(*js.Weight) {
+					return nil, fmt.Errorf("line %d: invalid weight: %v", line, *js.Weight)
+				}
+
+				sample.Weight = *js.Weight
+			}
+
+			sample.Meta = js.Meta
+
+			return sample, nil
+		}
+
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+
+		return nil, io.EOF
+	}
+}
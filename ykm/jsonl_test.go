@@ -0,0 +1,84 @@
+package ykm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		weights []float64
+		err     string
+	}{
+		{"weighted.tsv", "ID\tTree\tSentence\tWeight\tSource\na\t(γ α)\ta\t2.5\twiki\nb\t(γ α)\tb\t\tnews\n", []float64{2.5, 1}, ""},
+		{"negative.tsv", "ID\tTree\tSentence\tWeight\na\t(γ α)\ta\t-1\n", nil, "invalid weight"},
+		{"nan.tsv", "ID\tTree\tSentence\tWeight\na\t(γ α)\ta\tNaN\n", nil, "invalid weight"},
+		{"weighted.jsonl", `{"id": "a", "tree": "(γ α)", "sentence": "a", "weight": 0, "meta": {"source": "wiki"}}` + "\n\n" + `{"id": "b", "tree": "(γ α)", "sentence": "b"}` + "\n", []float64{0, 1}, ""},
+		{"negative.jsonl", `{"tree": "(γ α)", "sentence": "a", "weight": -1}` + "\n", nil, "line 1: invalid weight"},
+		{"missing.jsonl", `{"tree": "(γ α)", "sentence": "a"}` + "\n" + `{"tree": "(γ α)"}` + "\n", nil, "line 2: missing tree or sentence"},
+	}
+
+	for _, tt := range tests {
+		samples, err := readCorpus(t, writeCorpus(t, tt.name, tt.content), FormatAuto)
+
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: expected an error containing %q, got %v", tt.name, tt.err, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+
+			continue
+		}
+
+		if len(samples) != len(tt.weights) {
+			t.Fatalf("%s: got %d samples, want %d", tt.name, len(samples), len(tt.weights))
+		}
+
+		for i, s := range samples {
+			if s.Weight != tt.weights[i] {
+				t.Errorf("%s: sample %s has weight %v, want %v", tt.name, s.ID, s.Weight, tt.weights[i])
+			}
+		}
+
+		// the other columns and the meta object are kept as metadata
+		if meta := samples[0].Meta; meta["Source"] != "wiki" && meta["source"] != "wiki" {
+			t.Errorf("%s: got meta %v", tt.name, samples[0].Meta)
+		}
+	}
+}
+
+func TestTrainWeighted(t *testing.T) {
+	const foo = "foo\t(σ (γ α) (γ β))\ts b a\t1\t"
+	const bar = "bar\t(σ (s (γ b) (γ a)))\tα β\t1\t"
+
+	corpora := []string{
+		"ID\tTree\tSentence\tLabel\tWeight\n" + foo + "2\n" + bar + "1\n" + bar + "0\n",
+		"ID\tTree\tSentence\tLabel\tWeight\n" + foo + "1\n" + foo + "1\n" + bar + "1\n",
+	}
+
+	checksums := make([]string, len(corpora))
+
+	for i, corpus := range corpora {
+		path := writeCorpus(t, "weighted.tsv", corpus)
+
+		f := newFixture(t, func(o *Options) {
+			o.TrainingDataPath = path
+			o.EnableReproducibleTraining = true
+			o.TrainingIterationLimit = 2
+		})
+
+		checksums[i], _ = Checksum(tablesOnly(f.train()))
+	}
+
+	// zero-weight samples are left out
+	if checksums[0] != checksums[1] {
+		t.Errorf("a sample of weight 2 trains differently from two copies: %s, %s", checksums[0], checksums[1])
+	}
+}
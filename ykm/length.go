@@ -0,0 +1,105 @@
+package ykm
+
+import (
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// LengthCount is the number of samples whose tree has Source leaves and whose
+// sentence has Target tokens.
+type LengthCount struct {
+	Source int
+	Target int
+	Count  int
+}
+
+// CountLengths counts the source and target lengths of the positive samples
+// of a corpus, sorted by source and target length.
+func CountLengths(name string, m *Model, limit int) ([]LengthCount, error) {
+	it, err := NewIterator(name, m.opts.CorpusFormat)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer it.Close()
+
+	counts := make(map[[2]int]int)
+
+	counted := 0
+
+	for it.Next() && (limit == -1 || counted < limit) {
+		sample := it.Sample()
+
+		counted++
+
+		if !sample.Positive() {
+			continue
+		}
+
+		t, err := DecodeTree(sample.Tree, m.opts.TreeFormat)
+
+		if err != nil {
+			continue
+		}
+
+		counts[[2]int{len(t.Leaves()), len(strings.Split(sample.Sentence, " "))}]++
+	}
+
+	if err := it.Error(); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	lengths := make([]LengthCount, 0, len(counts))
+
+	for k, c := range counts {
+		lengths = append(lengths, LengthCount{k[0], k[1], c})
+	}
+
+	sort.Slice(lengths, func(i, j int) bool {
+		if lengths[i].Source != lengths[j].Source {
+			return lengths[i].Source < lengths[j].Source
+		}
+
+		return lengths[i].Target < lengths[j].Target
+	})
+
+	return lengths, nil
+}
+
+// lengthModel is the distribution of target lengths given the number of
+// source leaves. It uses add-one smoothing over the lengths up to the longest
+// observed sentence and one bucket for all longer sentences.
+type lengthModel struct {
+	max    int
+	counts map[[2]int]int
+	totals map[int]int
+}
+
+func newLengthModel(lengths []LengthCount) lengthModel {
+	lm := lengthModel{
+		counts: make(map[[2]int]int, len(lengths)),
+		totals: make(map[int]int),
+	}
+
+	for _, l := range lengths {
+		lm.counts[[2]int{l.Source, l.Target}] += l.Count
+		lm.totals[l.Source] += l.Count
+
+		if l.Target > lm.max {
+			lm.max = l.Target
+		}
+	}
+
+	return lm
+}
+
+func (lm lengthModel) logProbability(source, target int) float64 {
+	if target > lm.max {
+		target = lm.max + 1
+	}
+
+	return math.Log(float64(lm.counts[[2]int{source, target}]+1) / float64(lm.totals[source]+lm.max+1))
+}
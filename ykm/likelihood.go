@@ -0,0 +1,106 @@
+package ykm
+
+import (
+	"context"
+	"fmt"
+	"golang.org/x/sync/semaphore"
+	"io"
+	"math"
+	"sync"
+)
+
+type Likelihood struct {
+	Total     float64
+	Tokens    int
+	Evaluated int
+	Skipped   int
+}
+
+func (lh Likelihood) PerToken() float64 {
+	if lh.Tokens == 0 {
+		return math.Inf(-1)
+	}
+
+	return lh.Total / float64(lh.Tokens)
+}
+
+func (lh Likelihood) String() string {
+	return fmt.Sprintf("total: %f per-token: %f (eval: %d skip: %d tokens: %d)", lh.Total, lh.PerToken(), lh.Evaluated, lh.Skipped, lh.Tokens)
+}
+
+func HeldOutLikelihood(name string, m *Model) (Likelihood, error) {
+	lh := Likelihood{}
+
+	it, err := NewIterator(name, m.opts.CorpusFormat)
+
+	if err != nil {
+		return lh, err
+	}
+
+	defer it.Close()
+
+	ctx := context.TODO()
+	sem := semaphore.NewWeighted(int64(m.opts.ConcurrentSampleEvaluations))
+
+	var wg sync.WaitGroup
+
+	results := make([]*Likelihood, 0)
+
+	for it.Next() {
+		if !it.Sample().Positive() {
+			continue
+		}
+
+		mt, e, err := m.InitSample(it.Sample())
+
+		if err != nil {
+			lh.Skipped++
+
+			continue
+		}
+
+		if err := sem.Acquire(ctx, 1); err != nil {
+			return lh, fmt.Errorf("failed to acquire semaphore: %w", err)
+		}
+
+		wg.Add(1)
+
+		r := &Likelihood{Skipped: 1}
+
+		results = append(results, r)
+
+		go func() {
+			defer sem.Release(1)
+			defer wg.Done()
+
+			g, err := NewGraph(mt, e, m)
+
+			if err != nil {
+				return
+			}
+
+			p := g.LogProbability()
+
+			if math.IsInf(p, -1) {
+				return
+			}
+
+			*r = Likelihood{Total: p, Tokens: len(e), Evaluated: 1}
+		}()
+	}
+
+	wg.Wait()
+
+	if err := it.Error(); err != nil && err != io.EOF {
+		return lh, err
+	}
+
+	for _, r := range results {
+		lh.Total += r.Total
+		lh.Tokens += r.Tokens
+		lh.Evaluated += r.Evaluated
+		lh.Skipped += r.Skipped
+	}
+
+	return lh, nil
+}
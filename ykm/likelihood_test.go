@@ -0,0 +1,92 @@
+package ykm
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestHeldOutLikelihood(t *testing.T) {
+	f := newFixture(t, nil)
+
+	m := f.train()
+
+	data, err := os.ReadFile(mockCorpus)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the last sample has zero probability and is skipped
+	heldOut := writeCorpus(t, "held-out.tsv", strings.TrimSuffix(string(data), "\n")+"\nzero\t(σ (γ α) (γ β))\ts b zzz\t1\n")
+
+	lh, err := HeldOutLikelihood(heldOut, m)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := 0.0
+
+	graphs, _ := f.graphs(m)
+
+	for _, g := range graphs {
+		want += g.LogProbability()
+	}
+
+	if math.Abs(lh.Total-want) > 1e-9 || lh.Tokens != 5 || lh.Evaluated != 2 || lh.Skipped != 1 {
+		t.Errorf("got held-out likelihood %s, want total %f over 5 tokens", lh, want)
+	}
+
+	if got := (Likelihood{}).PerToken(); !math.IsInf(got, -1) {
+		t.Errorf("per-token likelihood of no tokens = %v, want -Inf", got)
+	}
+}
+
+func TestEarlyStopping(t *testing.T) {
+	f := newFixture(t, func(o *Options) {
+		o.TrainingIterationLimit = 10
+		o.HeldOutDataPath = mockCorpus
+		o.EnableEarlyStopping = true
+		o.EarlyStoppingTolerance = 1e9
+	})
+
+	tr := NewTrainer(f.opts)
+
+	var out bytes.Buffer
+
+	tr.SetOutput(&out)
+
+	best, err := tr.Train()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first iteration always improves on nothing
+	if !strings.Contains(out.String(), "Stopping early after iteration #2") {
+		t.Errorf("training did not stop after the second iteration:\n%s", out.String())
+	}
+
+	first := newFixture(t, func(o *Options) {
+		o.TrainingIterationLimit = 1
+	}).train()
+
+	lhBest, err := HeldOutLikelihood(mockCorpus, best)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lhFirst, err := HeldOutLikelihood(mockCorpus, first)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lhBest.PerToken() < lhFirst.PerToken() {
+		t.Errorf("best model has held-out likelihood %s, first iteration %s", lhBest, lhFirst)
+	}
+}
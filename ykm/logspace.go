@@ -0,0 +1,65 @@
+package ykm
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+)
+
+var logZero = math.Inf(-1)
+
+func logAdd(a, b float64) float64 {
+	if math.IsInf(a, -1) {
+		return b
+	}
+
+	if math.IsInf(b, -1) {
+		return a
+	}
+
+	if a < b {
+		a, b = b, a
+	}
+
+	return a + math.Log1p(math.Exp(b-a))
+}
+
+func LogOf(f *big.Float) float64 {
+	if f.Sign() == 0 {
+		return logZero
+	}
+
+	mant := new(big.Float)
+	exp := f.MantExp(mant)
+
+	m, _ := mant.Float64()
+
+	return math.Log(m) + float64(exp)*math.Ln2
+}
+
+func ExpOf(x float64) *big.Float {
+	if math.IsInf(x, -1) {
+		return new(big.Float)
+	}
+
+	if math.IsInf(x, 1) {
+		return new(big.Float).SetInf(false)
+	}
+
+	exp := math.Floor(x / math.Ln2)
+	mant := math.Exp(x - exp*math.Ln2)
+
+	return new(big.Float).SetMantExp(big.NewFloat(mant), int(exp))
+}
+
+// JSONFloat is a float64 that encodes infinities and NaN as null, which JSON
+// cannot represent.
+type JSONFloat float64
+
+func (f JSONFloat) MarshalJSON() ([]byte, error) {
+	if math.IsInf(float64(f), 0) || math.IsNaN(float64(f)) {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(float64(f))
+}
@@ -1,4 +1,4 @@
-package main
+package ykm
 
 import (
 	"github.com/jonasknobloch/jinn/pkg/tree"
@@ -16,6 +16,7 @@ type MetaTree struct {
 	Tree    *tree.Tree
 	meta    map[*tree.Tree][3]string
 	unknown map[*tree.Tree][3]string
+	backoff map[*tree.Tree][3][]string
 	maxF    map[*tree.Tree]int
 }
 
@@ -26,26 +27,49 @@ func NewMetaTree(t *tree.Tree) *MetaTree {
 		Tree:    t,
 		meta:    make(map[*tree.Tree][3]string, size),
 		unknown: make(map[*tree.Tree][3]string, size),
+		backoff: make(map[*tree.Tree][3][]string, size),
 		maxF:    make(map[*tree.Tree]int, size),
 	}
 
 	return m
 }
 
-func (mt *MetaTree) CollectFeatures() {
+func (mt *MetaTree) CollectFeatures(opts Options) {
+	chains := opts.backoff()
+
 	var walk func(p, st *tree.Tree)
 	walk = func(p, st *tree.Tree) {
-		mt.Annotate(st, [3]string{
+		exact := [3]string{
 			nFeature(p, st, false),
 			rFeature(st, false),
 			tFeature(st, false),
-		}, false)
+		}
 
-		mt.Annotate(st, [3]string{
+		unknown := [3]string{
 			nFeature(p, st, true),
 			rFeature(st, true),
 			tFeature(st, true),
-		}, true)
+		}
+
+		mt.Annotate(st, exact, false)
+		mt.Annotate(st, unknown, true)
+
+		var backoff [3][]string
+
+		for nf, chain := range chains {
+			seen := make(map[string]bool, len(chain))
+
+			for _, level := range chain {
+				feature := backoffFeature(level, NodeFeature(nf), p, st, exact, unknown)
+
+				if !seen[feature] {
+					seen[feature] = true
+					backoff[nf] = append(backoff[nf], feature)
+				}
+			}
+		}
+
+		mt.backoff[st] = backoff
 
 		for _, c := range st.Children {
 			walk(st, c)
@@ -55,7 +79,7 @@ func (mt *MetaTree) CollectFeatures() {
 	walk(nil, mt.Tree)
 }
 
-func (mt *MetaTree) ComputeMaxFertility() {
+func (mt *MetaTree) ComputeMaxFertility(opts Options) {
 	min := func(a, b int) int {
 		if a < b {
 			return a
@@ -69,12 +93,12 @@ func (mt *MetaTree) ComputeMaxFertility() {
 		phrasal := 0
 		lexical := 0
 
-		if Config.EnableInteriorInsertions && len(st.Children) != 0 {
+		if opts.EnableInteriorInsertions && len(st.Children) != 0 {
 			phrasal += 1
 			lexical += 1
 		}
 
-		if Config.EnableTerminalInsertions && len(st.Children) == 0 {
+		if opts.EnableTerminalInsertions && len(st.Children) == 0 {
 			lexical += 1
 		}
 
@@ -82,8 +106,8 @@ func (mt *MetaTree) ComputeMaxFertility() {
 			lexical += 1
 		}
 
-		if Config.EnablePhrasalTranslations && len(st.Children) != 0 {
-			phrasal += min(len(st.Children)+Config.MaxPhraseLengthDifference, Config.PhraseLengthLimit)
+		if opts.EnablePhrasalTranslations && len(st.Children) != 0 {
+			phrasal += min(len(st.Children)+opts.MaxPhraseLengthDifference, opts.PhraseLengthLimit)
 		}
 
 		for _, c := range st.Children {
@@ -122,8 +146,8 @@ func (mt *MetaTree) Unknown(st *tree.Tree) ([3]string, bool) {
 	return u, ok
 }
 
-func (mt *MetaTree) Feature(st *tree.Tree, nf NodeFeature) [2]string {
-	a, ok := mt.Annotation(st)
+func (mt *MetaTree) Feature(st *tree.Tree, nf NodeFeature) Features {
+	b, ok := mt.backoff[st]
 
 	if !ok {
 		panic("unknown feature")
@@ -135,7 +159,7 @@ func (mt *MetaTree) Feature(st *tree.Tree, nf NodeFeature) [2]string {
 		panic("unknown feature")
 	}
 
-	return [2]string{a[nf], u[nf]}
+	return Features{Backoff: b[nf], Unknown: u[nf]}
 }
 
 func (mt *MetaTree) MaxFertility(st *tree.Tree) int {
@@ -0,0 +1,359 @@
+package ykm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+type Model struct {
+	n map[string]map[string]*big.Float
+	r map[string]map[string]*big.Float
+	t map[string]map[string]*big.Float
+
+	l map[string]map[string]*big.Float
+	f map[string]map[string]*big.Float
+
+	info ModelInfo
+	opts Options
+
+	warnings []string
+
+	vocabulary map[string]int
+	lengths    []LengthCount
+	phrasal    map[string]map[string]int
+
+	keys *keySpace
+
+	divergence float64
+
+	training bool
+}
+
+func NewModel(opts Options) *Model {
+	return &Model{
+		opts: opts,
+		keys: &keySpace{},
+
+		n: make(map[string]map[string]*big.Float),
+		r: make(map[string]map[string]*big.Float),
+		t: make(map[string]map[string]*big.Float),
+
+		l: make(map[string]map[string]*big.Float),
+		f: make(map[string]map[string]*big.Float),
+	}
+}
+
+func (m *Model) Copy() *Model {
+	c := NewModel(m.opts)
+
+	copyTable := func(dst, src map[string]map[string]*big.Float) {
+		for feature, keys := range src {
+			dst[feature] = make(map[string]*big.Float, len(keys))
+
+			for key, p := range keys {
+				dst[feature][key] = new(big.Float).Copy(p)
+			}
+		}
+	}
+
+	copyTable(c.n, m.n)
+	copyTable(c.r, m.r)
+	copyTable(c.t, m.t)
+	copyTable(c.l, m.l)
+	copyTable(c.f, m.f)
+
+	c.info = m.info
+	c.vocabulary = m.vocabulary
+	c.lengths = m.lengths
+	c.phrasal = m.phrasal
+
+	return c
+}
+
+func (m *Model) Prepare(name string) error {
+	var err error
+
+	if m.opts.ReplaceSparseTokens {
+		if m.vocabulary, err = CountTokenOccurrences(name, m.opts.CorpusFormat, m.opts.TrainingSampleLimit); err != nil {
+			return err
+		}
+	}
+
+	if m.lengths, err = CountLengths(name, m, m.opts.TrainingSampleLimit); err != nil {
+		return err
+	}
+
+	if m.opts.EnablePhrasalTranslations {
+		if m.phrasal, err = CountPhrasalPairs(name, m, m.opts.TrainingSampleLimit); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Model) Options() Options {
+	return m.opts
+}
+
+func (m *Model) Info() ModelInfo {
+	return m.info
+}
+
+func (m *Model) SetInfo(info ModelInfo) {
+	m.info = info
+}
+
+// Warnings returns the problems found while loading the model.
+func (m *Model) Warnings() []string {
+	return m.warnings
+}
+
+// Divergence returns the divergence of the variational posterior from its
+// prior after the last weight update.
+func (m *Model) Divergence() float64 {
+	return m.divergence
+}
+
+func (m *Model) Table(op Operation) map[string]map[string]*big.Float {
+	return m.table(tableName(op))
+}
+
+func (m *Model) table(name string) map[string]map[string]*big.Float {
+	switch name {
+	case "n":
+		return m.n
+	case "r":
+		return m.r
+	case "t":
+		return m.t
+	case "l":
+		return m.l
+	case "f":
+		return m.f
+	default:
+		panic("unexpected table")
+	}
+}
+
+func tableName(op Operation) string {
+	switch op.(type) {
+	case Insertion:
+		return "n"
+	case Reordering:
+		return "r"
+	case Translation:
+		return "t"
+	default:
+		panic("unexpected operation type")
+	}
+}
+
+func (m *Model) Probability(op Operation) *big.Float {
+	lookup := func(table map[string]map[string]*big.Float, features, keys []string) *big.Float {
+		for _, feature := range features {
+			for _, key := range keys {
+				if p, ok := table[feature][key]; ok {
+					return p
+				}
+			}
+		}
+
+		if m.stepwise() {
+			return big.NewFloat(0.1)
+		}
+
+		return new(big.Float)
+	}
+
+	probability := func(name string, features, keys []string) *big.Float {
+		table := m.table(name)
+
+		if len(table) == 0 {
+			return big.NewFloat(0.1)
+		}
+
+		var p *big.Float
+
+		if m.opts.smoothed() {
+			p = m.smoothedProbability(name, table, features, keys)
+		} else {
+			p = lookup(table, features, keys)
+		}
+
+		if m.opts.ProbabilityFloor > 0 && p.Cmp(big.NewFloat(m.opts.ProbabilityFloor)) < 0 {
+			return big.NewFloat(m.opts.ProbabilityFloor)
+		}
+
+		return p
+	}
+
+	operationProbability := func(op Operation) *big.Float {
+		features := Features{op.Backoff(), op.UnknownFeature()}.Lookup()
+		keys := []string{op.Key(), op.UnknownKey()}
+
+		return probability(tableName(op), features, keys)
+	}
+
+	if translation, ok := op.(Translation); ok && m.opts.EnablePhrasalTranslations {
+		key := strconv.Itoa(translation.Fertility[1])
+		features := Features{op.Backoff(), op.UnknownFeature()}.Lookup()
+		fertility := probability("f", features, []string{key})
+
+		if translation.Fertility[1] == 0 {
+			return fertility
+		}
+
+		p := new(big.Float).Copy(fertility)
+
+		if translation.Fertility[1] == 1 || !m.opts.EnableFertilityDecomposition {
+			p.Mul(p, operationProbability(translation))
+
+			return p
+		}
+
+		if translation.Fertility[1] > 1 {
+			for _, t := range translation.Decompose() {
+				p.Mul(p, operationProbability(t))
+			}
+		}
+
+		return p
+	}
+
+	return operationProbability(op)
+}
+
+func (m *Model) LogProbability(op Operation) float64 {
+	return LogOf(m.Probability(op))
+}
+
+func (m *Model) Lambda(feature string) (*big.Float, *big.Float) {
+	var lambda *big.Float
+	var kappa *big.Float
+
+	if len(m.l) == 0 {
+		lambda = big.NewFloat(0.5)
+		kappa = big.NewFloat(0.5)
+
+		return lambda, kappa
+	}
+
+	if _, ok := m.l[feature]; !ok {
+		if m.stepwise() {
+			lambda = big.NewFloat(0.5)
+			kappa = big.NewFloat(0.5)
+
+			return lambda, kappa
+		}
+
+		if !m.opts.EnablePhrasalTranslations && m.training {
+			panic("unknown feature")
+		}
+
+		lambda = new(big.Float)
+		kappa = big.NewFloat(1)
+
+		return lambda, kappa
+	}
+
+	lambda = m.l[feature][LambdaKey]
+	kappa = m.l[feature][KappaKey]
+
+	return lambda, kappa
+}
+
+// stepwise reports whether the model is being trained with online EM. Its
+// tables then only cover the mini-batches seen so far, so unseen entries
+// keep their initial weights instead of dropping to zero.
+func (m *Model) stepwise() bool {
+	return m.training && m.opts.EnableOnlineEM
+}
+
+func (m *Model) UpdateWeights(insertionCount, reorderingCount, translationCount, lambdaCount, fertilityCount *Count) error {
+	m.keys = &keySpace{}
+	m.divergence = 0
+
+	update := func(name string, p map[string]map[string]*big.Float, c *Count) error {
+		if m.opts.TrainingMethod == MethodVB && name != "l" {
+			m.divergence += m.updateVariational(name, p, c)
+
+			return nil
+		}
+
+		if m.opts.smoothed() && name != "l" {
+			return m.updateSmoothed(name, p, c)
+		}
+
+		if c.logSpace {
+			return updateLog(p, c)
+		}
+
+		for _, feature := range c.Features() {
+			sum := c.Sum(feature)
+
+			if sum.Cmp(new(big.Float)) == 0 {
+				return errors.New("invalid counter sum for feature: " + feature)
+			}
+
+			if _, ok := p[feature]; !ok {
+				p[feature] = make(map[string]*big.Float, len(c.val[feature]))
+			}
+
+			for _, key := range c.FeatureKeys(feature) {
+				if _, ok := p[feature][key]; !ok {
+					p[feature][key] = new(big.Float)
+				}
+
+				p[feature][key].Quo(c.Get(feature, key), sum)
+			}
+		}
+
+		return nil
+	}
+
+	if err := update("n", m.n, insertionCount); err != nil {
+		return fmt.Errorf("insertion: %w", err)
+	}
+
+	if err := update("r", m.r, reorderingCount); err != nil {
+		return fmt.Errorf("reordering: %w", err)
+	}
+
+	if err := update("t", m.t, translationCount); err != nil {
+		return fmt.Errorf("translation: %w", err)
+	}
+
+	if err := update("l", m.l, lambdaCount); err != nil {
+		return fmt.Errorf("lambda: %w", err)
+	}
+
+	if err := update("f", m.f, fertilityCount); err != nil {
+		return fmt.Errorf("fertility: %w", err)
+	}
+
+	return nil
+}
+
+func updateLog(p map[string]map[string]*big.Float, c *Count) error {
+	for _, feature := range c.Features() {
+		sum := c.LogSum(feature)
+
+		if math.IsInf(sum, -1) || math.IsNaN(sum) {
+			return errors.New("invalid counter sum for feature: " + feature)
+		}
+
+		if _, ok := p[feature]; !ok {
+			p[feature] = make(map[string]*big.Float, len(c.log[feature]))
+		}
+
+		for _, key := range c.FeatureKeys(feature) {
+			p[feature][key] = ExpOf(c.log[feature][key] - sum)
+		}
+	}
+
+	return nil
+}
@@ -1,4 +1,4 @@
-package main
+package ykm
 
 import (
 	"github.com/jonasknobloch/jinn/pkg/tree"
@@ -27,8 +27,22 @@ type Node struct {
 	lambda *big.Float
 	kappa  *big.Float
 	valid  bool
+
+	logLambda float64
+	logKappa  float64
 }
 
 func (n *Node) Substring() string {
 	return strings.Join(n.f[n.k:n.k+n.l], " ")
 }
+
+func (n *Node) Yield() string {
+	leaves := n.tree.Leaves()
+	labels := make([]string, len(leaves))
+
+	for i, leaf := range leaves {
+		labels[i] = leaf.Label
+	}
+
+	return strings.Join(labels, " ")
+}
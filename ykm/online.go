@@ -0,0 +1,265 @@
+package ykm
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"math/rand"
+	"strconv"
+)
+
+type batchResult struct {
+	likelihood *big.Float
+	tokens     int
+	scored     int
+	skipped    []string
+}
+
+func loadSamples(opts Options) ([]*Sample, error) {
+	corpus, err := NewIterator(opts.TrainingDataPath, opts.CorpusFormat)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer corpus.Close()
+
+	samples := make([]*Sample, 0)
+	counter := 0
+
+	for corpus.Next() && (opts.TrainingSampleLimit == -1 || counter < opts.TrainingSampleLimit) {
+		counter++
+
+		if sample := corpus.Sample(); sample.Positive() && sample.Weight != 0 {
+			samples = append(samples, sample)
+		}
+	}
+
+	if err := corpus.Error(); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return samples, nil
+}
+
+func (tr *Trainer) collect(model *Model, samples []*Sample, counts [5]*Count) (batchResult, error) {
+	acc := newAccumulator(counts, tr.opts.ConcurrentSampleEvaluations, batchResult{
+		likelihood: big.NewFloat(1),
+		skipped:    make([]string, 0),
+	})
+
+	acc.evaluated = func(r sampleResult) {
+		if r.skip != nil {
+			tr.printf("Skipped sample %s (%s)\n", r.id, r.skip)
+		}
+	}
+
+	model.training = true
+
+	for eval, sample := range samples {
+		mt, e, err := model.InitSample(sample)
+
+		if err != nil {
+			acc.skip(sample.ID, err)
+
+			continue
+		}
+
+		if err := acc.submit(model, sample, mt, e, eval, nil); err != nil {
+			acc.close()
+
+			model.training = false
+
+			return acc.batchResult, err
+		}
+	}
+
+	acc.close()
+
+	model.training = false
+
+	return acc.batchResult, nil
+}
+
+// trainOnline runs stepwise EM. The sufficient statistics are interpolated
+// with the rescaled counts of every mini-batch using the step size
+// (k+2)^-STEP_SIZE_EXPONENT before the weights are updated.
+func (tr *Trainer) trainOnline(hash string, o int) (*Model, error) {
+	opts := tr.opts
+	model := tr.model
+
+	samples, err := loadSamples(opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no training samples in %s", opts.TrainingDataPath)
+	}
+
+	newCount := newCounter(opts)
+
+	stats := [5]*Count{newCount(), newCount(), newCount(), newCount(), newCount()}
+
+	rng := rand.New(rand.NewSource(int64(opts.RandomSeed)))
+
+	watch := NewStopWatch()
+
+	var best *Model
+	var bestIteration int
+	var bestLikelihood Likelihood
+
+	k := 0
+
+	for i := 1 + o; i < opts.TrainingIterationLimit+o+1; i++ {
+		watch.Start()
+
+		tr.printf("\nStarting training iteration #%d\n\n", i)
+
+		rng.Shuffle(len(samples), func(a, b int) {
+			samples[a], samples[b] = samples[b], samples[a]
+		})
+
+		training := Likelihood{}
+
+		for start := 0; start < len(samples); start += opts.OnlineBatchSize {
+			end := start + opts.OnlineBatchSize
+
+			if end > len(samples) {
+				end = len(samples)
+			}
+
+			counts := [5]*Count{newCount(), newCount(), newCount(), newCount(), newCount()}
+
+			res, err := tr.collect(model, samples[start:end], counts)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if opts.EnableFertilityDecomposition {
+				DecomposeTranslationCount(counts[2])
+			}
+
+			eta := math.Pow(float64(k+2), -opts.StepSizeExponent)
+			scale := float64(len(samples)) / float64(end-start)
+
+			for j := range stats {
+				stats[j].Scale(1 - eta)
+				stats[j].Merge(counts[j], eta*scale)
+			}
+
+			if err := model.UpdateWeights(stats[0], stats[1], stats[2], stats[3], stats[4]); err != nil {
+				return nil, fmt.Errorf("error updating model weights: %w", err)
+			}
+
+			k++
+
+			batch := Likelihood{
+				Total:     LogOf(res.likelihood),
+				Tokens:    res.tokens,
+				Evaluated: res.scored,
+				Skipped:   len(res.skipped),
+			}
+
+			training.Total += batch.Total
+			training.Tokens += batch.Tokens
+			training.Evaluated += batch.Evaluated
+			training.Skipped += batch.Skipped
+
+			tr.printf("Update #%d (step size: %f) batch log-likelihood: %s\n", k, eta, batch)
+
+			if opts.ExportModel && opts.OnlineExportInterval > 0 && k%opts.OnlineExportInterval == 0 {
+				model.info = ModelInfo{
+					Iteration:  i,
+					Config:     opts,
+					CorpusPath: opts.TrainingDataPath,
+					CorpusHash: hash,
+					Vocabulary: model.vocabulary,
+					Lengths:    model.lengths,
+				}
+
+				if err := Export(model, strconv.Itoa(i), strconv.Itoa(k)); err != nil {
+					return nil, fmt.Errorf("error exporting model: %w", err)
+				}
+			}
+		}
+
+		watch.Lap("samples")
+
+		if opts.ExportModel {
+			model.info = ModelInfo{
+				Iteration:  i,
+				Config:     opts,
+				CorpusPath: opts.TrainingDataPath,
+				CorpusHash: hash,
+				Vocabulary: model.vocabulary,
+				Lengths:    model.lengths,
+			}
+
+			if err := Export(model, strconv.Itoa(i)); err != nil {
+				return nil, fmt.Errorf("error exporting model: %w", err)
+			}
+
+			watch.Lap("export")
+		}
+
+		tr.printf("\nTraining log-likelihood: %s\n", training)
+
+		stop := false
+
+		if opts.HeldOutDataPath != "" {
+			heldOut, err := HeldOutLikelihood(opts.HeldOutDataPath, model)
+
+			if err != nil {
+				return nil, fmt.Errorf("error computing held-out likelihood: %w", err)
+			}
+
+			tr.printf("Held-out log-likelihood: %s\n", heldOut)
+
+			if best == nil || heldOut.PerToken() > bestLikelihood.PerToken() {
+				improvement := heldOut.PerToken() - bestLikelihood.PerToken()
+
+				best, bestIteration, bestLikelihood = model.Copy(), i, heldOut
+
+				stop = opts.EnableEarlyStopping && improvement < opts.EarlyStoppingTolerance
+			} else {
+				stop = opts.EnableEarlyStopping
+			}
+
+			watch.Lap("likelihood")
+		}
+
+		tr.printf("\n")
+
+		watch.Stop()
+
+		tr.printf("%s", watch)
+
+		watch.Reset()
+
+		if stop {
+			tr.printf("\nStopping early after iteration #%d (best: #%d)\n", i, bestIteration)
+
+			break
+		}
+	}
+
+	if best == nil {
+		return model, nil
+	}
+
+	tr.printf("\nBest held-out log-likelihood after iteration #%d: %s\n", bestIteration, bestLikelihood)
+
+	tr.model = best
+
+	if opts.ExportModel {
+		if err := Export(best, "final"); err != nil {
+			return nil, fmt.Errorf("error exporting model: %w", err)
+		}
+	}
+
+	return best, nil
+}
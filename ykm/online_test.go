@@ -0,0 +1,114 @@
+package ykm
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// diffTables returns the largest difference between the weights of two models
+// and whether both have the same features and keys.
+func diffTables(a, b *Model) (float64, bool) {
+	diff := 0.0
+
+	tables := b.Tables()
+
+	for name, t := range a.Tables() {
+		if len(t) != len(tables[name]) {
+			return 0, false
+		}
+
+		for feature, keys := range t {
+			if len(keys) != len(tables[name][feature]) {
+				return 0, false
+			}
+
+			for key, p := range keys {
+				q, ok := tables[name][feature][key]
+
+				if !ok {
+					return 0, false
+				}
+
+				x, _ := p.Float64()
+				y, _ := q.Float64()
+
+				diff = math.Max(diff, math.Abs(x-y))
+			}
+		}
+	}
+
+	return diff, true
+}
+
+func TestOnlineFullBatch(t *testing.T) {
+	batch := newFixture(t, func(o *Options) {
+		o.TrainingIterationLimit = 1
+	}).train()
+
+	// the first update normalizes the halved counts of the whole corpus
+	online := newFixture(t, func(o *Options) {
+		o.TrainingIterationLimit = 1
+		o.EnableOnlineEM = true
+		o.OnlineBatchSize = 10
+	}).train()
+
+	if diff, ok := diffTables(online, batch); !ok || diff > 1e-12 {
+		t.Errorf("online update over the full corpus differs from batch EM (same keys: %t, difference: %e)", ok, diff)
+	}
+}
+
+func TestOnlineMiniBatches(t *testing.T) {
+	dir := t.TempDir()
+
+	checksums := make([]string, 2)
+
+	for i := range checksums {
+		f := newFixture(t, func(o *Options) {
+			o.EnableReproducibleTraining = true
+			o.TrainingIterationLimit = 3
+			o.EnableOnlineEM = true
+			o.OnlineBatchSize = 1
+			o.RandomSeed = 7
+			o.ExportModel = true
+			o.ModelExportDirectory = dir
+			o.OnlineExportInterval = 2
+		})
+
+		checksums[i], _ = Checksum(f.train())
+	}
+
+	if checksums[0] != checksums[1] {
+		t.Errorf("two runs with the same seed have checksums %s and %s", checksums[0], checksums[1])
+	}
+
+	// two updates per iteration, exported after every second one
+	for _, name := range []string{"model_1-2.gob", "model_2-4.gob", "model_3-6.gob", "model_3.gob"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("missing export: %v", err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "model_1-1.gob")); err == nil {
+		t.Error("exported the model after the first update")
+	}
+}
+
+func TestOnlineOptions(t *testing.T) {
+	opts := DefaultOptions()
+
+	opts.EnableOnlineEM = true
+	opts.CheckpointInterval = 1
+
+	if err := opts.Validate(); err == nil {
+		t.Error("expected an error for online EM with checkpoints")
+	}
+
+	opts.CheckpointInterval = 0
+	opts.StepSizeExponent = 0.5
+
+	if err := opts.Validate(); err == nil {
+		t.Error("expected an error for a step size exponent of 0.5")
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package ykm
 
 import (
 	"github.com/jonasknobloch/jinn/pkg/tree"
@@ -13,6 +13,31 @@ type Operation interface {
 
 	UnknownFeature() string
 	UnknownKey() string
+
+	Backoff() []string
+}
+
+// Features holds the backoff chain of an operation, starting with the exact
+// feature, and the feature with all leaves replaced by the unknown token.
+type Features struct {
+	Backoff []string
+	Unknown string
+}
+
+func NewFeatures(exact, unknown string) Features {
+	return Features{Backoff: []string{exact}, Unknown: unknown}
+}
+
+// Lookup returns the backoff chain followed by the unknown feature if the
+// chain does not already contain it.
+func (f Features) Lookup() []string {
+	for _, feature := range f.Backoff {
+		if feature == f.Unknown {
+			return f.Backoff
+		}
+	}
+
+	return append(f.Backoff[:len(f.Backoff):len(f.Backoff)], f.Unknown)
 }
 
 type InsertPosition string
@@ -22,14 +47,14 @@ const Left InsertPosition = "l"
 const Right InsertPosition = "r"
 
 type Insertion struct {
-	feature [2]string
+	feature Features
 	key     [2]string
 
 	Position InsertPosition
 	Word     string
 }
 
-func NewInsertion(pos InsertPosition, word string, feature [2]string) Insertion {
+func NewInsertion(pos InsertPosition, word string, feature Features) Insertion {
 	key := func(word string) string {
 		k := string(pos)
 
@@ -52,7 +77,7 @@ func NewInsertion(pos InsertPosition, word string, feature [2]string) Insertion
 }
 
 func (i Insertion) Feature() string {
-	return i.feature[0]
+	return i.feature.Backoff[0]
 }
 
 func (i Insertion) Key() string {
@@ -60,21 +85,25 @@ func (i Insertion) Key() string {
 }
 
 func (i Insertion) UnknownFeature() string {
-	return i.feature[1]
+	return i.feature.Unknown
 }
 
 func (i Insertion) UnknownKey() string {
 	return i.key[1]
 }
 
-func Insertions(t *tree.Tree, d []string, maxF int, f [2]string) []Operation {
+func (i Insertion) Backoff() []string {
+	return i.feature.Backoff
+}
+
+func Insertions(t *tree.Tree, d []string, maxF int, f Features, opts Options) []Operation {
 	ops := make([]Operation, 0)
 
-	if Config.EnableInteriorInsertions && len(t.Children) != 0 {
+	if opts.EnableInteriorInsertions && len(t.Children) != 0 {
 		maxF -= 1
 	}
 
-	if Config.EnableTerminalInsertions && len(t.Children) == 0 {
+	if opts.EnableTerminalInsertions && len(t.Children) == 0 {
 		maxF -= 1
 	}
 
@@ -86,11 +115,11 @@ func Insertions(t *tree.Tree, d []string, maxF int, f [2]string) []Operation {
 		ops = append(ops, NewInsertion(None, "", f))
 	}
 
-	if !Config.EnableInteriorInsertions && len(t.Children) != 0 {
+	if !opts.EnableInteriorInsertions && len(t.Children) != 0 {
 		return ops
 	}
 
-	if !Config.EnableTerminalInsertions && len(t.Children) == 0 {
+	if !opts.EnableTerminalInsertions && len(t.Children) == 0 {
 		return ops
 	}
 
@@ -103,13 +132,13 @@ func Insertions(t *tree.Tree, d []string, maxF int, f [2]string) []Operation {
 }
 
 type Reordering struct {
-	feature [2]string
+	feature Features
 	key     [2]string
 
 	Reordering []int
 }
 
-func NewReordering(reordering []int, feature [2]string) Reordering {
+func NewReordering(reordering []int, feature Features) Reordering {
 	join := func(p []int) string {
 		sb := strings.Builder{}
 
@@ -134,7 +163,7 @@ func NewReordering(reordering []int, feature [2]string) Reordering {
 }
 
 func (r Reordering) Feature() string {
-	return r.feature[0]
+	return r.feature.Backoff[0]
 }
 
 func (r Reordering) Key() string {
@@ -142,14 +171,18 @@ func (r Reordering) Key() string {
 }
 
 func (r Reordering) UnknownFeature() string {
-	return r.feature[1]
+	return r.feature.Unknown
 }
 
 func (r Reordering) UnknownKey() string {
 	return r.key[1]
 }
 
-func Reorderings(t *tree.Tree, f [2]string) []Operation {
+func (r Reordering) Backoff() []string {
+	return r.feature.Backoff
+}
+
+func Reorderings(t *tree.Tree, f Features) []Operation {
 	ops := make([]Operation, 0)
 
 	if len(t.Children) == 0 {
@@ -166,7 +199,7 @@ func Reorderings(t *tree.Tree, f [2]string) []Operation {
 }
 
 type Translation struct {
-	feature [2]string
+	feature Features
 	key     [2]string
 
 	Word      string
@@ -175,7 +208,7 @@ type Translation struct {
 
 const NullToken = "$NULL$"
 
-func NewTranslation(word string, feature [2]string) Translation {
+func NewTranslation(word string, feature Features) Translation {
 	if word == "" {
 		word = NullToken
 	}
@@ -185,7 +218,7 @@ func NewTranslation(word string, feature [2]string) Translation {
 		Word:    word,
 	}
 
-	t.Fertility[0] = len(strings.Split(feature[0], " "))
+	t.Fertility[0] = len(strings.Split(feature.Backoff[0], " "))
 
 	if word == NullToken {
 		t.Fertility[1] = 0 // len(strings.Split("", " ")) == 1
@@ -205,7 +238,7 @@ func NewTranslation(word string, feature [2]string) Translation {
 }
 
 func (t Translation) Feature() string {
-	return t.feature[0]
+	return t.feature.Backoff[0]
 }
 
 func (t Translation) Key() string {
@@ -213,13 +246,17 @@ func (t Translation) Key() string {
 }
 
 func (t Translation) UnknownFeature() string {
-	return t.feature[1]
+	return t.feature.Unknown
 }
 
 func (t Translation) UnknownKey() string {
 	return t.key[1]
 }
 
+func (t Translation) Backoff() []string {
+	return t.feature.Backoff
+}
+
 func (t Translation) Decompose() []Translation {
 	ts := make([]Translation, 0, t.Fertility[1])
 
@@ -0,0 +1,197 @@
+package ykm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type Options struct {
+	ReplaceSparseTokens          bool    `env:"REPLACE_SPARSE_TOKENS" default:"false"`
+	SparseTokenThreshold         int     `env:"SPARSE_TOKEN_THRESHOLD" default:"1"`
+	EnableInteriorInsertions     bool    `env:"ENABLE_INTERIOR_INSERTIONS" default:"false"`
+	EnableTerminalInsertions     bool    `env:"ENABLE_TERMINAL_INSERTIONS" default:"true"`
+	EnablePhrasalTranslations    bool    `env:"ENABLE_PHRASAL_TRANSLATIONS" default:"false"`
+	EnableFertilityDecomposition bool    `env:"ENABLE_FERTILITY_DECOMPOSITION" default:"true"`
+	PhraseLengthLimit            int     `env:"PHRASE_LENGTH_LIMIT" default:"0"`
+	MaxPhraseLengthDifference    int     `env:"MAX_PHRASE_LENGTH_DIFFERENCE" default:"0"`
+	PhraseFrequencyCutoff        int     `env:"PHRASE_FREQUENCY_CUTOFF" default:"1"`
+	InsertionBackoff             string  `env:"INSERTION_BACKOFF" default:"exact"`
+	ReorderingBackoff            string  `env:"REORDERING_BACKOFF" default:"exact"`
+	TranslationBackoff           string  `env:"TRANSLATION_BACKOFF" default:"exact"`
+	Smoothing                    string  `env:"SMOOTHING" default:"none"`
+	InsertionPseudoCount         float64 `env:"INSERTION_PSEUDO_COUNT" default:"0.01"`
+	ReorderingPseudoCount        float64 `env:"REORDERING_PSEUDO_COUNT" default:"0.01"`
+	TranslationPseudoCount       float64 `env:"TRANSLATION_PSEUDO_COUNT" default:"0.01"`
+	FertilityPseudoCount         float64 `env:"FERTILITY_PSEUDO_COUNT" default:"0.01"`
+	ProbabilityFloor             float64 `env:"PROBABILITY_FLOOR" default:"0"`
+	TrainingMethod               string  `env:"TRAINING_METHOD" default:"em"`
+	InsertionConcentration       float64 `env:"INSERTION_CONCENTRATION" default:"0.1"`
+	ReorderingConcentration      float64 `env:"REORDERING_CONCENTRATION" default:"0.1"`
+	TranslationConcentration     float64 `env:"TRANSLATION_CONCENTRATION" default:"0.1"`
+	FertilityConcentration       float64 `env:"FERTILITY_CONCENTRATION" default:"0.1"`
+	TrainingDataPath             string  `env:"TRAINING_DATA_PATH" default:""`
+	CorpusFormat                 string  `env:"CORPUS_FORMAT" default:"auto"`
+	TreeFormat                   string  `env:"TREE_FORMAT" default:"auto"`
+	TrainingIterationLimit       int     `env:"TRAINING_ITERATION_LIMIT" default:"1"`
+	TrainingSampleLimit          int     `env:"TRAINING_SAMPLE_LIMIT" default:"-1"`
+	TrainingComplexityLimit      int     `env:"TRAINING_COMPLEXITY_LIMIT" default:"-1"`
+	EnableOnlineEM               bool    `env:"ENABLE_ONLINE_EM" default:"false"`
+	OnlineBatchSize              int     `env:"ONLINE_BATCH_SIZE" default:"1000"`
+	StepSizeExponent             float64 `env:"STEP_SIZE_EXPONENT" default:"0.7"`
+	OnlineExportInterval         int     `env:"ONLINE_EXPORT_INTERVAL" default:"0"`
+	RandomSeed                   int     `env:"RANDOM_SEED" default:"1"`
+	EnableReproducibleTraining   bool    `env:"ENABLE_REPRODUCIBLE_TRAINING" default:"false"`
+	HeldOutDataPath              string  `env:"HELD_OUT_DATA_PATH" default:""`
+	EnableEarlyStopping          bool    `env:"ENABLE_EARLY_STOPPING" default:"false"`
+	EarlyStoppingTolerance       float64 `env:"EARLY_STOPPING_TOLERANCE" default:"1e-4"`
+	ConcurrentSampleEvaluations  int     `env:"CONCURRENT_SAMPLE_EVALUATIONS" default:"1"`
+	LogSpaceArithmetic           bool    `env:"LOG_SPACE_ARITHMETIC" default:"false"`
+	EnableCountCorrection        bool    `env:"ENABLE_COUNT_CORRECTION" default:"false"`
+	InitModelPath                string  `env:"INIT_MODEL_PATH" default:""`
+	InitModelIteration           int     `env:"INIT_MODEL_ITERATION" default:"1"`
+	CheckpointInterval           int     `env:"CHECKPOINT_INTERVAL" default:"0"`
+	CheckpointPath               string  `env:"CHECKPOINT_PATH" default:"checkpoint.gob"`
+	ResumeCheckpoint             bool    `env:"RESUME_CHECKPOINT" default:"false"`
+	PrintCorpusLikelihood        bool    `env:"PRINT_CORPUS_LIKELIHOOD" default:"false"`
+	ExportGraphs                 bool    `env:"EXPORT_GRAPHS" default:"false"`
+	ExportModel                  bool    `env:"EXPORT_MODEL" default:"true"`
+	GraphExportDirectory         string  `env:"GRAPH_EXPORT_DIRECTORY" default:""`
+	ModelExportDirectory         string  `env:"MODEL_EXPORT_DIRECTORY" default:""`
+	ModelExportFormat            string  `env:"MODEL_EXPORT_FORMAT" default:"gob"`
+}
+
+// DefaultOptions returns the options set to the defaults of their struct tags.
+func DefaultOptions() Options {
+	o := Options{}
+
+	v := reflect.ValueOf(&o).Elem()
+
+	for i := 0; i < v.NumField(); i++ {
+		if err := SetOption(v.Field(i), v.Type().Field(i).Tag.Get("default")); err != nil {
+			panic(fmt.Errorf("%s: %w", v.Type().Field(i).Tag.Get("env"), err))
+		}
+	}
+
+	return o
+}
+
+// SetOption parses val into a string, boolean, integer or float field.
+func SetOption(field reflect.Value, val string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+
+		if err != nil {
+			return fmt.Errorf("invalid boolean: %q", val)
+		}
+
+		field.SetBool(b)
+	case reflect.Int:
+		i, err := strconv.Atoi(val)
+
+		if err != nil {
+			return fmt.Errorf("invalid integer: %q", val)
+		}
+
+		field.SetInt(int64(i))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(val, 64)
+
+		if err != nil {
+			return fmt.Errorf("invalid number: %q", val)
+		}
+
+		field.SetFloat(f)
+	default:
+		panic("unexpected option type")
+	}
+
+	return nil
+}
+
+func (o Options) Check() []string {
+	errs := make([]string, 0)
+
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(o.SparseTokenThreshold >= 0, "SPARSE_TOKEN_THRESHOLD must not be negative")
+	check(o.PhraseLengthLimit >= 0, "PHRASE_LENGTH_LIMIT must not be negative")
+	check(o.MaxPhraseLengthDifference >= 0, "MAX_PHRASE_LENGTH_DIFFERENCE must not be negative")
+	check(o.PhraseFrequencyCutoff >= 0, "PHRASE_FREQUENCY_CUTOFF must not be negative")
+	check(!o.EnablePhrasalTranslations || o.PhraseLengthLimit > 0, "ENABLE_PHRASAL_TRANSLATIONS requires PHRASE_LENGTH_LIMIT > 0")
+
+	backoff := func(name, chain string, nf NodeFeature) {
+		err := checkBackoff(chain, nf)
+
+		check(err == nil, "invalid %s: %v", name, err)
+	}
+
+	backoff("INSERTION_BACKOFF", o.InsertionBackoff, InsertionFeature)
+	backoff("REORDERING_BACKOFF", o.ReorderingBackoff, ReorderingFeature)
+	backoff("TRANSLATION_BACKOFF", o.TranslationBackoff, TranslationFeature)
+
+	check(o.Smoothing == SmoothingNone || o.Smoothing == SmoothingAdditive || o.Smoothing == SmoothingWittenBell, "unknown SMOOTHING: %s", o.Smoothing)
+	check(o.Smoothing != SmoothingAdditive || o.InsertionPseudoCount > 0 && o.ReorderingPseudoCount > 0 && o.TranslationPseudoCount > 0 && o.FertilityPseudoCount > 0, "SMOOTHING=additive requires positive pseudo-counts")
+	check(o.ProbabilityFloor >= 0 && o.ProbabilityFloor < 1, "PROBABILITY_FLOOR must be in [0, 1)")
+
+	check(o.TrainingMethod == MethodEM || o.TrainingMethod == MethodVB, "unknown TRAINING_METHOD: %s", o.TrainingMethod)
+	check(o.TrainingMethod != MethodVB || o.InsertionConcentration > 0 && o.ReorderingConcentration > 0 && o.TranslationConcentration > 0 && o.FertilityConcentration > 0, "TRAINING_METHOD=vb requires positive concentrations")
+	check(o.TrainingMethod != MethodVB || !o.smoothed(), "TRAINING_METHOD=vb cannot be combined with SMOOTHING")
+
+	check(o.CorpusFormat == FormatAuto || o.CorpusFormat == FormatTSV || o.CorpusFormat == FormatJSONL || o.CorpusFormat == FormatCoNLLU, "unknown CORPUS_FORMAT: %s", o.CorpusFormat)
+	check(o.TreeFormat == FormatAuto || o.TreeFormat == FormatPTB || o.TreeFormat == FormatCoNLLU, "unknown TREE_FORMAT: %s", o.TreeFormat)
+
+	check(o.TrainingIterationLimit >= 1, "TRAINING_ITERATION_LIMIT must be positive")
+	check(o.TrainingSampleLimit >= -1, "TRAINING_SAMPLE_LIMIT must be -1 or non-negative")
+	check(o.TrainingComplexityLimit >= -1, "TRAINING_COMPLEXITY_LIMIT must be -1 or non-negative")
+	check(o.ConcurrentSampleEvaluations >= 1, "CONCURRENT_SAMPLE_EVALUATIONS must be positive")
+
+	check(o.OnlineBatchSize >= 1, "ONLINE_BATCH_SIZE must be positive")
+	check(o.StepSizeExponent > 0.5 && o.StepSizeExponent <= 1, "STEP_SIZE_EXPONENT must be in (0.5, 1]")
+	check(o.OnlineExportInterval >= 0, "ONLINE_EXPORT_INTERVAL must not be negative")
+	check(!o.EnableOnlineEM || o.CheckpointInterval == 0 && !o.ResumeCheckpoint, "ENABLE_ONLINE_EM does not support checkpoints")
+
+	check(!o.EnableEarlyStopping || o.HeldOutDataPath != "", "ENABLE_EARLY_STOPPING requires HELD_OUT_DATA_PATH")
+	check(o.EarlyStoppingTolerance >= 0, "EARLY_STOPPING_TOLERANCE must not be negative")
+
+	check(o.ModelExportFormat == FormatGob || o.ModelExportFormat == FormatTSV || o.ModelExportFormat == FormatJSON, "unknown MODEL_EXPORT_FORMAT: %s", o.ModelExportFormat)
+
+	check(o.CheckpointInterval >= 0, "CHECKPOINT_INTERVAL must not be negative")
+	check(!o.ResumeCheckpoint || o.CheckpointPath != "", "RESUME_CHECKPOINT requires CHECKPOINT_PATH")
+	check(!o.ResumeCheckpoint || o.InitModelPath == "", "RESUME_CHECKPOINT and INIT_MODEL_PATH are mutually exclusive")
+
+	return errs
+}
+
+func (o Options) backoff() [3][]string {
+	chains := [3][]string{
+		InsertionFeature:   parseBackoff(o.InsertionBackoff),
+		ReorderingFeature:  parseBackoff(o.ReorderingBackoff),
+		TranslationFeature: parseBackoff(o.TranslationBackoff),
+	}
+
+	for nf, chain := range chains {
+		if len(chain) == 0 {
+			chains[nf] = []string{LevelExact}
+		}
+	}
+
+	return chains
+}
+
+func (o Options) Validate() error {
+	if errs := o.Check(); len(errs) > 0 {
+		return errors.New("invalid options:\n  " + strings.Join(errs, "\n  "))
+	}
+
+	return nil
+}
@@ -1,22 +1,20 @@
-package main
+package ykm
 
 import (
-	"fmt"
 	"github.com/jonasknobloch/jinn/pkg/tree"
 	"github.com/jonasknobloch/jinn/pkg/utility"
+	"io"
 	"strings"
 )
 
-var phrasalFrequencies map[string]map[string]int
+func CountPhrasalPairs(name string, m *Model, limit int) (map[string]map[string]int, error) {
+	it, err := NewIterator(name, m.opts.CorpusFormat)
 
-func initPhrasalFrequencies() {
-	phrasalFrequencies = countPhrasalPairs()
-}
-
-func countPhrasalPairs() map[string]map[string]int {
-	fmt.Println("Counting phrasal pairs...")
+	if err != nil {
+		return nil, err
+	}
 
-	initCorpus()
+	defer it.Close()
 
 	pairs := make(map[string]map[string]int)
 
@@ -33,11 +31,11 @@ func countPhrasalPairs() map[string]map[string]int {
 			return false
 		}
 
-		if len(es) > Config.PhraseLengthLimit || len(et) > Config.PhraseLengthLimit {
+		if len(es) > m.opts.PhraseLengthLimit || len(et) > m.opts.PhraseLengthLimit {
 			return false
 		}
 
-		if abs(len(es), len(et)) > Config.MaxPhraseLengthDifference {
+		if abs(len(es), len(et)) > m.opts.MaxPhraseLengthDifference {
 			return false
 		}
 
@@ -52,12 +50,12 @@ func countPhrasalPairs() map[string]map[string]int {
 		pairs[feature][key]++
 	}
 
-	counted, limit := 0, Config.TrainingSampleLimit
+	counted := 0
 
-	for corpus.Next() && (limit == -1 || counted < limit) {
-		sample := corpus.Sample()
+	for it.Next() && (limit == -1 || counted < limit) {
+		sample := it.Sample()
 
-		mt, e, err := initSample(sample)
+		mt, e, err := m.InitSample(sample)
 
 		if err != nil {
 			continue
@@ -75,7 +73,7 @@ func countPhrasalPairs() map[string]map[string]int {
 				add(source, "")
 			}
 
-			for i := 1; i <= Config.PhraseLengthLimit; i++ {
+			for i := 1; i <= m.opts.PhraseLengthLimit; i++ {
 				for _, ngram := range utility.NGrams(e, i, nil) {
 					if !valid(sourceTokens, ngram) {
 						continue
@@ -90,5 +88,9 @@ func countPhrasalPairs() map[string]map[string]int {
 		counted++
 	}
 
-	return pairs
+	if err := it.Error(); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return pairs, nil
 }
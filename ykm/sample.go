@@ -0,0 +1,104 @@
+package ykm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type Sample struct {
+	ID       string
+	Tree     string
+	Sentence string
+	Label    bool
+	Labeled  bool
+	Weight   float64
+	Meta     map[string]interface{}
+}
+
+// NewSample creates a sample. An empty label marks an unlabeled sample, any
+// other value than "0" or "1" is rejected. Readers reject empty labels of
+// corpora that have labels.
+func NewSample(id, tree, sentence, label string) (*Sample, error) {
+	out := &Sample{
+		ID:       id,
+		Tree:     tree,
+		Sentence: sentence,
+		Weight:   1,
+	}
+
+	switch label {
+	case "":
+	case "0":
+		out.Labeled = true
+	case "1":
+		out.Label, out.Labeled = true, true
+	default:
+		return nil, fmt.Errorf("unknown quality: %q", label)
+	}
+
+	return out, nil
+}
+
+func parseWeight(val string) (float64, error) {
+	if val == "" {
+		return 1, nil
+	}
+
+	w, err := strconv.ParseFloat(val, 64)
+
+	if err != nil || !validWeight(w) {
+		return 0, fmt.Errorf("invalid weight: %q", val)
+	}
+
+	return w, nil
+}
+
+func validWeight(w float64) bool {
+	return w >= 0 && !math.IsInf(w, 0) && !math.IsNaN(w)
+}
+
+// Positive reports whether the sample is labeled as a paraphrase. Only
+// positive samples are trained on, unlabeled samples can only be scored.
+func (s *Sample) Positive() bool {
+	return s.Labeled && s.Label
+}
+
+func (m *Model) InitSample(sample *Sample) (*MetaTree, []string, error) {
+	t, err := DecodeTree(sample.Tree, m.opts.TreeFormat)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sparse := m.opts.ReplaceSparseTokens && m.vocabulary != nil
+
+	if sparse {
+		replaceSparseLabels(t.Leaves(), m.vocabulary, m.opts.SparseTokenThreshold)
+	}
+
+	mt := NewMetaTree(t)
+
+	mt.CollectFeatures(m.opts)
+	mt.ComputeMaxFertility(m.opts)
+
+	e := strings.Split(sample.Sentence, " ")
+
+	if sparse {
+		replaceSparseTokens(e, m.vocabulary, m.opts.SparseTokenThreshold)
+	}
+
+	if len(e) > mt.MaxFertility(mt.Tree) {
+		return nil, nil, errors.New("target sentence unreachable")
+	}
+
+	c, ok := O(mt.Tree, len(e), m.opts)
+
+	if !ok || (m.opts.TrainingComplexityLimit != -1 && m.opts.TrainingComplexityLimit < c) {
+		return nil, nil, errors.New("sample exceeds complexity limit")
+	}
+
+	return mt, e, nil
+}
@@ -0,0 +1,106 @@
+package ykm
+
+import (
+	"strings"
+	"testing"
+)
+
+const conlluSample = "1\tx\tx\tNOUN\t_\t_\t0\troot\t_\t_\n"
+
+func TestNewSample(t *testing.T) {
+	tests := []struct {
+		label    string
+		labeled  bool
+		positive bool
+	}{
+		{"", false, false},
+		{"0", true, false},
+		{"1", true, true},
+	}
+
+	for _, tt := range tests {
+		s, err := NewSample("id", "(γ α)", "a", tt.label)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if s.Labeled != tt.labeled || s.Positive() != tt.positive {
+			t.Errorf("label %q: labeled %t positive %t, want %t and %t", tt.label, s.Labeled, s.Positive(), tt.labeled, tt.positive)
+		}
+	}
+
+	for _, label := range []string{"2", "true", " 1"} {
+		if _, err := NewSample("id", "(γ α)", "a", label); err == nil {
+			t.Errorf("label %q: expected an error", label)
+		}
+	}
+}
+
+func TestReadLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		labels  []string
+		err     string
+	}{
+		{"labeled.tsv", "ID\tTree\tSentence\tLabel\na\t(γ α)\ta\t1\nb\t(γ α)\tb\t0\n", []string{"1", "0"}, ""},
+		{"unlabeled.tsv", "ID\tTree\tSentence\na\t(γ α)\ta\n", []string{""}, ""},
+		{"empty.tsv", "ID\tTree\tSentence\tLabel\na\t(γ α)\ta\t1\nb\t(γ α)\tb\t\n", nil, "sample b: empty label"},
+		{"labeled.jsonl", `{"tree": "(γ α)", "sentence": "a", "label": true}` + "\n" + `{"tree": "(γ α)", "sentence": "a", "label": 0}` + "\n", []string{"1", "0"}, ""},
+		{"unlabeled.jsonl", `{"tree": "(γ α)", "sentence": "a", "label": null}` + "\n" + `{"tree": "(γ α)", "sentence": "a"}` + "\n", []string{"", ""}, ""},
+		{"empty.jsonl", `{"tree": "(γ α)", "sentence": "a", "label": ""}` + "\n", nil, "invalid label"},
+		{"labeled.conllu", "# target = x\n# label = 1\n" + conlluSample + "\n", []string{"1"}, ""},
+		{"unlabeled.conllu", "# target = x\n" + conlluSample + "\n", []string{""}, ""},
+		{"empty.conllu", "# target = x\n# label =\n" + conlluSample + "\n", nil, "empty label comment"},
+	}
+
+	for _, tt := range tests {
+		samples, err := readCorpus(t, writeCorpus(t, tt.name, tt.content), FormatAuto)
+
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: expected an error containing %q, got %v", tt.name, tt.err, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+
+			continue
+		}
+
+		labels := make([]string, 0)
+
+		for _, s := range samples {
+			switch {
+			case !s.Labeled:
+				labels = append(labels, "")
+			case s.Label:
+				labels = append(labels, "1")
+			default:
+				labels = append(labels, "0")
+			}
+		}
+
+		if strings.Join(labels, ",") != strings.Join(tt.labels, ",") {
+			t.Errorf("%s: got labels %q, want %q", tt.name, labels, tt.labels)
+		}
+	}
+}
+
+func TestTrainUnlabeled(t *testing.T) {
+	corpus := writeCorpus(t, "unlabeled.tsv", "ID\tTree\tSentence\nfoo\t(σ (γ α) (γ β))\ts b a\n")
+
+	f := newFixture(t, func(o *Options) {
+		o.TrainingDataPath = corpus
+	})
+
+	m := f.train()
+
+	if len(m.t) != 0 || len(m.lengths) != 0 {
+		t.Errorf("expected no training on unlabeled samples but got %d translation features and %d lengths", len(m.t), len(m.lengths))
+	}
+}
@@ -0,0 +1,67 @@
+package ykm
+
+import "math/big"
+
+type Result struct {
+	Probability    *big.Float
+	LogProbability float64
+	Score          float64
+	Derivation     *Derivation
+}
+
+type Scorer struct {
+	model *Model
+	score ScoreFunction
+}
+
+func NewScorer(m *Model, normalization string) (*Scorer, error) {
+	score, err := NewScoreFunction(normalization, m)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Scorer{
+		model: m,
+		score: score,
+	}, nil
+}
+
+func (s *Scorer) Model() *Model {
+	return s.model
+}
+
+func (s *Scorer) Score(sample *Sample, derive bool) (Result, error) {
+	mt, e, err := s.model.InitSample(sample)
+
+	if err != nil {
+		return Result{}, err
+	}
+
+	g, err := NewGraph(mt, e, s.model)
+
+	if err != nil {
+		return Result{}, err
+	}
+
+	logp := g.LogProbability()
+
+	r := Result{
+		Probability:    g.Probability(),
+		LogProbability: logp,
+		Score:          s.score(logp, mt, e),
+	}
+
+	if derive {
+		steps, score := g.BestDerivation()
+
+		r.Derivation = &Derivation{
+			ID:             sample.ID,
+			Probability:    ExpOf(score),
+			LogProbability: JSONFloat(score),
+			Steps:          steps,
+		}
+	}
+
+	return r, nil
+}
@@ -0,0 +1,67 @@
+package ykm
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const NormalizationRaw = "raw"
+const NormalizationToken = "token"
+const NormalizationTree = "tree"
+const NormalizationBaseline = "baseline"
+
+type ScoreFunction func(logp float64, mt *MetaTree, e []string) float64
+
+// NewScoreFunction returns the score normalization with the given name. The
+// baseline normalization is the log ratio to a model that draws the target
+// length given the number of source leaves from the length statistics of the
+// training corpus, and every target token uniformly from the target
+// vocabulary of the translation table.
+func NewScoreFunction(name string, m *Model) (ScoreFunction, error) {
+	switch name {
+	case NormalizationRaw:
+		return func(logp float64, _ *MetaTree, _ []string) float64 {
+			return logp
+		}, nil
+	case NormalizationToken:
+		return func(logp float64, _ *MetaTree, e []string) float64 {
+			return logp / float64(len(e))
+		}, nil
+	case NormalizationTree:
+		return func(logp float64, mt *MetaTree, _ []string) float64 {
+			return logp / float64(mt.Tree.Size())
+		}, nil
+	case NormalizationBaseline:
+		if len(m.lengths) == 0 {
+			return nil, fmt.Errorf("score normalization %s requires a model with length statistics", name)
+		}
+
+		lm := newLengthModel(m.lengths)
+		logV := math.Log(float64(targetVocabularySize(m)))
+
+		return func(logp float64, mt *MetaTree, e []string) float64 {
+			return logp - lm.logProbability(len(mt.Tree.Leaves()), len(e)) + float64(len(e))*logV
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown score normalization: %s", name)
+	}
+}
+
+func targetVocabularySize(m *Model) int {
+	vocabulary := make(map[string]struct{})
+
+	for _, keys := range m.t {
+		for key := range keys {
+			for _, token := range strings.Split(key, " ") {
+				if token == NullToken || token == UnknownToken {
+					continue
+				}
+
+				vocabulary[token] = struct{}{}
+			}
+		}
+	}
+
+	return len(vocabulary) + 1
+}
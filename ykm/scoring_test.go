@@ -0,0 +1,84 @@
+package ykm
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCountLengths(t *testing.T) {
+	f := newFixture(t, nil)
+
+	lengths, err := CountLengths(mockCorpus, NewModel(f.opts), -1)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []LengthCount{{2, 2, 1}, {2, 3, 1}}
+
+	if !reflect.DeepEqual(lengths, want) {
+		t.Errorf("got %v, want %v", lengths, want)
+	}
+}
+
+func TestLengthModel(t *testing.T) {
+	lm := newLengthModel([]LengthCount{{2, 2, 3}, {2, 3, 1}, {4, 1, 2}})
+
+	// the longest sentence has 3 tokens, so there are 4 outcomes
+	if p := math.Exp(lm.logProbability(2, 2)); math.Abs(p-4.0/8) > 1e-12 {
+		t.Errorf("p(2 | 2) = %v, want %v", p, 4.0/8)
+	}
+
+	if p, q := lm.logProbability(2, 4), lm.logProbability(2, 10); p != q {
+		t.Errorf("longer sentences should share a bucket but got %v and %v", p, q)
+	}
+
+	for _, source := range []int{2, 4, 7} {
+		sum := 0.0
+
+		for target := 1; target <= 4; target++ {
+			sum += math.Exp(lm.logProbability(source, target))
+		}
+
+		if math.Abs(sum-1) > 1e-12 {
+			t.Errorf("length probabilities of source length %d sum to %v", source, sum)
+		}
+	}
+}
+
+func TestBaselineNormalization(t *testing.T) {
+	f := newFixture(t, nil)
+
+	if _, err := NewScoreFunction(NormalizationBaseline, NewModel(f.opts)); err == nil {
+		t.Error("expected an error for a model without length statistics")
+	}
+
+	m := f.train()
+
+	score, err := NewScoreFunction(NormalizationBaseline, m)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	graphs, samples := f.graphs(m)
+
+	logV := math.Log(float64(targetVocabularySize(m)))
+
+	for i, g := range graphs {
+		mt, e, err := m.InitSample(samples[i])
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// both samples have two source leaves and one of the two observed
+		// target lengths, out of 4 possible outcomes
+		want := g.LogProbability() - math.Log(2.0/6) + float64(len(e))*logV
+
+		if got := score(g.LogProbability(), mt, e); math.Abs(got-want) > 1e-12 {
+			t.Errorf("score of sample %s = %v, want %v", samples[i].ID, got, want)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package ykm
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+type ShardCounts struct {
+	Iteration  int
+	Shard      int
+	Shards     int
+	CorpusPath string
+	CorpusHash string
+	Vocabulary map[string]int
+	Lengths    []LengthCount
+	Likelihood Likelihood
+	Counts     [5]*Count
+}
+
+func WriteShardCounts(name string, sc *ShardCounts) error {
+	tmp := name + ".tmp"
+
+	file, err := os.Create(tmp)
+
+	if err != nil {
+		return fmt.Errorf("error creating file: %w", err)
+	}
+
+	if err := gob.NewEncoder(file).Encode(sc); err != nil {
+		file.Close()
+
+		return fmt.Errorf("error encoding counts: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error closing file: %w", err)
+	}
+
+	if err := os.Rename(tmp, name); err != nil {
+		return fmt.Errorf("error renaming file: %w", err)
+	}
+
+	return nil
+}
+
+func ReadShardCounts(name string) (*ShardCounts, error) {
+	file, err := os.Open(name)
+
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+
+	defer file.Close()
+
+	sc := &ShardCounts{}
+
+	if err := gob.NewDecoder(file).Decode(sc); err != nil {
+		return nil, fmt.Errorf("error decoding counts: %w", err)
+	}
+
+	return sc, nil
+}
+
+// TrainShard runs the E-step of a single iteration on every shards-th
+// training sample starting at index and returns the expected counts.
+func (tr *Trainer) TrainShard(index, shards int) (*ShardCounts, error) {
+	opts := tr.opts
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	if shards < 1 || index < 0 || index >= shards {
+		return nil, fmt.Errorf("invalid shard %d of %d", index, shards)
+	}
+
+	if opts.ResumeCheckpoint {
+		return nil, errors.New("sharded training does not support checkpoints")
+	}
+
+	hash, err := hashCorpus(opts.TrainingDataPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, o, err := tr.initModel()
+
+	if err != nil {
+		return nil, err
+	}
+
+	model := tr.model
+
+	if opts.InitModelPath != "" {
+		o = model.info.Iteration
+	}
+
+	if err := model.Prepare(opts.TrainingDataPath); err != nil {
+		return nil, err
+	}
+
+	corpus, err := NewIterator(opts.TrainingDataPath, opts.CorpusFormat)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer corpus.Close()
+
+	samples := make([]*Sample, 0)
+	eval := 0
+
+	for corpus.Next() && (opts.TrainingSampleLimit == -1 || eval < opts.TrainingSampleLimit) {
+		if sample := corpus.Sample(); sample.Positive() && sample.Weight != 0 {
+			if eval%shards == index {
+				samples = append(samples, sample)
+			}
+
+			eval++
+		}
+	}
+
+	if err := corpus.Error(); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	tr.printf("Evaluating shard %d of %d (%d samples)\n", index, shards, len(samples))
+
+	newCount := newCounter(opts)
+
+	counts := [5]*Count{newCount(), newCount(), newCount(), newCount(), newCount()}
+
+	res, err := tr.collect(model, samples, counts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sc := &ShardCounts{
+		Iteration:  o + 1,
+		Shard:      index,
+		Shards:     shards,
+		CorpusPath: opts.TrainingDataPath,
+		CorpusHash: hash,
+		Vocabulary: model.vocabulary,
+		Lengths:    model.lengths,
+		Likelihood: Likelihood{
+			Total:     LogOf(res.likelihood),
+			Tokens:    res.tokens,
+			Evaluated: res.scored,
+			Skipped:   len(res.skipped),
+		},
+		Counts: counts,
+	}
+
+	tr.printf("Shard log-likelihood: %s\n", sc.Likelihood)
+
+	return sc, nil
+}
+
+// MergeShards sums the expected counts of all shards of an iteration and
+// updates the weights of the model at INIT_MODEL_PATH, or of a new model if
+// there is none.
+func MergeShards(opts Options, shards []*ShardCounts) (*Model, Likelihood, error) {
+	lh := Likelihood{}
+
+	if len(shards) == 0 {
+		return nil, lh, errors.New("no shard counts to merge")
+	}
+
+	sort.Slice(shards, func(i, j int) bool {
+		return shards[i].Shard < shards[j].Shard
+	})
+
+	first := shards[0]
+
+	if len(shards) != first.Shards {
+		return nil, lh, fmt.Errorf("expected %d shards but got %d", first.Shards, len(shards))
+	}
+
+	opts.LogSpaceArithmetic = first.Counts[0].logSpace
+
+	newCount := newCounter(opts)
+
+	counts := [5]*Count{newCount(), newCount(), newCount(), newCount(), newCount()}
+
+	for i, sc := range shards {
+		switch {
+		case sc.Shard != i:
+			return nil, lh, fmt.Errorf("missing or duplicate shard %d", i)
+		case sc.Shards != first.Shards:
+			return nil, lh, fmt.Errorf("shard %d was written for %d shards", sc.Shard, sc.Shards)
+		case sc.Iteration != first.Iteration:
+			return nil, lh, fmt.Errorf("shard %d belongs to iteration %d", sc.Shard, sc.Iteration)
+		case sc.CorpusHash != first.CorpusHash:
+			return nil, lh, fmt.Errorf("shard %d was computed on a different corpus", sc.Shard)
+		case sc.Counts[0].logSpace != first.Counts[0].logSpace:
+			return nil, lh, fmt.Errorf("shard %d was computed with a different arithmetic backend", sc.Shard)
+		}
+
+		for j := range counts {
+			counts[j].Merge(sc.Counts[j], 1)
+		}
+
+		lh.Total += sc.Likelihood.Total
+		lh.Tokens += sc.Likelihood.Tokens
+		lh.Evaluated += sc.Likelihood.Evaluated
+		lh.Skipped += sc.Likelihood.Skipped
+	}
+
+	if opts.EnableFertilityDecomposition {
+		DecomposeTranslationCount(counts[2])
+	}
+
+	model := NewModel(opts)
+
+	if opts.InitModelPath != "" {
+		m, err := LoadModel(opts.InitModelPath, opts)
+
+		if err != nil {
+			return nil, lh, err
+		}
+
+		model = m
+	}
+
+	if iteration := model.info.Iteration + 1; first.Iteration != iteration {
+		return nil, lh, fmt.Errorf("shards belong to iteration %d but the initial model is at iteration %d", first.Iteration, model.info.Iteration)
+	}
+
+	if err := model.UpdateWeights(counts[0], counts[1], counts[2], counts[3], counts[4]); err != nil {
+		return nil, lh, fmt.Errorf("error updating model weights: %w", err)
+	}
+
+	model.vocabulary = first.Vocabulary
+	model.lengths = first.Lengths
+
+	model.info = ModelInfo{
+		Iteration:  first.Iteration,
+		Config:     opts,
+		CorpusPath: first.CorpusPath,
+		CorpusHash: first.CorpusHash,
+		Vocabulary: first.Vocabulary,
+		Lengths:    first.Lengths,
+	}
+
+	return model, lh, nil
+}
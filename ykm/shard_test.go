@@ -0,0 +1,114 @@
+package ykm
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// trainShards runs one sharded iteration on top of opts.InitModelPath and
+// passes the counts of every shard through a file.
+func trainShards(t *testing.T, opts Options, shards int) ([]*ShardCounts, *Model, Likelihood) {
+	t.Helper()
+
+	counts := make([]*ShardCounts, 0, shards)
+
+	for i := 0; i < shards; i++ {
+		sc, err := NewTrainer(opts).TrainShard(i, shards)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		name := filepath.Join(t.TempDir(), fmt.Sprintf("counts-%d.gob", i))
+
+		if err := WriteShardCounts(name, sc); err != nil {
+			t.Fatal(err)
+		}
+
+		if sc, err = ReadShardCounts(name); err != nil {
+			t.Fatal(err)
+		}
+
+		counts = append(counts, sc)
+	}
+
+	m, lh, err := MergeShards(opts, counts)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return counts, m, lh
+}
+
+func TestShardedTraining(t *testing.T) {
+	for _, shards := range []int{1, 2, 3} {
+		dir := t.TempDir()
+
+		f := newFixture(t, func(o *Options) {
+			o.ModelExportDirectory = dir
+		})
+
+		opts := f.opts
+
+		for iteration := 1; iteration <= 2; iteration++ {
+			_, m, lh := trainShards(t, opts, shards)
+
+			batch := newFixture(t, func(o *Options) {
+				o.TrainingIterationLimit = iteration
+			}).train()
+
+			if diff, ok := diffTables(m, batch); !ok || diff > 1e-12 {
+				t.Errorf("%d shards, iteration %d: merged model differs from batch EM (same keys: %t, difference: %e)", shards, iteration, ok, diff)
+			}
+
+			if lh.Evaluated != 2 || lh.Tokens != 5 || m.Info().Iteration != iteration {
+				t.Errorf("%d shards, iteration %d: got likelihood %s of iteration %d", shards, iteration, lh, m.Info().Iteration)
+			}
+
+			if err := Export(m, strconv.Itoa(iteration)); err != nil {
+				t.Fatal(err)
+			}
+
+			opts.InitModelPath = filepath.Join(dir, "model_"+strconv.Itoa(iteration)+".gob")
+		}
+	}
+}
+
+func TestMergeShardErrors(t *testing.T) {
+	f := newFixture(t, nil)
+
+	counts, _, _ := trainShards(t, f.opts, 2)
+
+	other := *counts[1]
+
+	other.CorpusHash = "other"
+
+	later := *counts[1]
+
+	later.Iteration = 2
+
+	tests := []struct {
+		shards []*ShardCounts
+		err    string
+	}{
+		{nil, "no shard counts"},
+		{counts[:1], "expected 2 shards"},
+		{[]*ShardCounts{counts[0], counts[0]}, "missing or duplicate shard 1"},
+		{[]*ShardCounts{counts[0], &other}, "different corpus"},
+		{[]*ShardCounts{counts[0], &later}, "belongs to iteration 2"},
+	}
+
+	for _, tt := range tests {
+		if _, _, err := MergeShards(f.opts, tt.shards); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("got error %v, want %q", err, tt.err)
+		}
+	}
+
+	if _, err := NewTrainer(f.opts).TrainShard(2, 2); err == nil {
+		t.Error("expected an error for shard 2 of 2")
+	}
+}
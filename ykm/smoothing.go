@@ -0,0 +1,161 @@
+package ykm
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+)
+
+const SmoothingNone = "none"
+const SmoothingAdditive = "additive"
+const SmoothingWittenBell = "witten-bell"
+
+// BackoffKey holds the probability mass a smoothed feature reserves for its
+// backoff distribution.
+const BackoffKey = "$BACKOFF$"
+
+func (o Options) smoothed() bool {
+	return o.Smoothing != "" && o.Smoothing != SmoothingNone
+}
+
+type keySpace struct {
+	once sync.Once
+	size map[string]int
+}
+
+func (m *Model) keySpace(table string) int {
+	if m.keys == nil {
+		return len(tableKeys(m.table(table))) + 1
+	}
+
+	m.keys.once.Do(func() {
+		m.keys.size = make(map[string]int)
+
+		for name, t := range m.Tables() {
+			m.keys.size[name] = len(tableKeys(t)) + 1 // one bucket for unseen keys
+		}
+	})
+
+	return m.keys.size[table]
+}
+
+func tableKeys(table map[string]map[string]*big.Float) map[string]bool {
+	keys := make(map[string]bool)
+
+	for _, row := range table {
+		for key := range row {
+			if key != BackoffKey {
+				keys[key] = true
+			}
+		}
+	}
+
+	return keys
+}
+
+func (m *Model) pseudoCount(table string) float64 {
+	switch table {
+	case "n":
+		return m.opts.InsertionPseudoCount
+	case "r":
+		return m.opts.ReorderingPseudoCount
+	case "t":
+		return m.opts.TranslationPseudoCount
+	case "f":
+		return m.opts.FertilityPseudoCount
+	default:
+		panic("unexpected table")
+	}
+}
+
+func (m *Model) updateSmoothed(name string, p map[string]map[string]*big.Float, c *Count) error {
+	v := len(c.Keys()) + 1
+	zero := new(big.Float)
+
+	for _, feature := range c.Features() {
+		sum := c.Sum(feature)
+
+		if sum.Cmp(zero) == 0 {
+			return errors.New("invalid counter sum for feature: " + feature)
+		}
+
+		var d *big.Float
+
+		switch m.opts.Smoothing {
+		case SmoothingAdditive:
+			d = big.NewFloat(m.pseudoCount(name) * float64(v))
+		case SmoothingWittenBell:
+			types := 0
+
+			for _, key := range c.FeatureKeys(feature) {
+				if c.Get(feature, key).Cmp(zero) > 0 {
+					types++
+				}
+			}
+
+			d = big.NewFloat(float64(types))
+		}
+
+		den := new(big.Float).Add(sum, d)
+
+		if _, ok := p[feature]; !ok {
+			p[feature] = make(map[string]*big.Float, c.Size(feature)+1)
+		}
+
+		for _, key := range c.FeatureKeys(feature) {
+			p[feature][key] = new(big.Float).Quo(c.Get(feature, key), den)
+		}
+
+		p[feature][BackoffKey] = new(big.Float).Quo(d, den)
+	}
+
+	return nil
+}
+
+// smoothedProbability interpolates from the uniform distribution over the key
+// space of a table towards the most specific feature. Witten-Bell backs off
+// through every level of the chain while additive smoothing only uses the most
+// specific known feature.
+func (m *Model) smoothedProbability(name string, table map[string]map[string]*big.Float, features, keys []string) *big.Float {
+	p := new(big.Float).Quo(big.NewFloat(1), big.NewFloat(float64(m.keySpace(name))))
+
+	levels := features
+
+	if m.opts.Smoothing == SmoothingAdditive {
+		for i, feature := range features {
+			if _, ok := table[feature]; ok {
+				levels = features[i : i+1]
+
+				break
+			}
+		}
+	}
+
+	for i := len(levels) - 1; i >= 0; i-- {
+		row, ok := table[levels[i]]
+
+		if !ok {
+			continue
+		}
+
+		q := new(big.Float)
+
+		for _, key := range keys {
+			if v, ok := row[key]; ok {
+				q.Set(v)
+
+				break
+			}
+		}
+
+		if bo, ok := row[BackoffKey]; ok {
+			q.Add(q, new(big.Float).Mul(bo, p))
+		} else if q.Sign() == 0 {
+			continue
+		}
+
+		p = q
+	}
+
+	return p
+}
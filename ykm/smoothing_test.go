@@ -0,0 +1,82 @@
+package ykm
+
+import (
+	"math"
+	"testing"
+)
+
+func smoothedModel(t *testing.T, smoothing string) *Model {
+	t.Helper()
+
+	f := newFixture(t, func(o *Options) {
+		o.Smoothing = smoothing
+		o.TranslationPseudoCount = 0.5
+	})
+
+	m := NewModel(f.opts)
+
+	c := f.count(map[string]map[string]float64{
+		"a": {"x": 3, "y": 1},
+		"b": {"x": 2},
+	})
+
+	if err := m.updateSmoothed("t", m.t, c); err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+func TestSmoothedProbability(t *testing.T) {
+	// two seen keys and one bucket for unseen keys
+	uniform := 1.0 / 3
+
+	tests := []struct {
+		smoothing string
+		feature   string
+		key       string
+		want      float64
+	}{
+		// d = 0.5 * 3, p = c/(4+d) + d/(4+d) * 1/3
+		{SmoothingAdditive, "a", "x", 3/5.5 + 1.5/5.5*uniform},
+		{SmoothingAdditive, "a", "y", 1/5.5 + 1.5/5.5*uniform},
+		{SmoothingAdditive, "a", "z", 1.5 / 5.5 * uniform},
+		{SmoothingAdditive, "c", "x", uniform},
+		// d = number of seen types, p = c/(4+2) + 2/(4+2) * 1/3
+		{SmoothingWittenBell, "a", "x", 3.0/6 + 2.0/6*uniform},
+		{SmoothingWittenBell, "a", "z", 2.0 / 6 * uniform},
+		{SmoothingWittenBell, "b", "x", 2.0/3 + 1.0/3*uniform},
+		{SmoothingWittenBell, "b", "y", 1.0 / 3 * uniform},
+	}
+
+	for _, tt := range tests {
+		m := smoothedModel(t, tt.smoothing)
+
+		p, _ := m.smoothedProbability("t", m.t, []string{tt.feature}, []string{tt.key}).Float64()
+
+		if math.Abs(p-tt.want) > 1e-12 {
+			t.Errorf("%s p(%s | %s) = %v, want %v", tt.smoothing, tt.key, tt.feature, p, tt.want)
+		}
+	}
+}
+
+func TestSmoothedDistribution(t *testing.T) {
+	for _, smoothing := range []string{SmoothingAdditive, SmoothingWittenBell} {
+		m := smoothedModel(t, smoothing)
+
+		for _, feature := range []string{"a", "b", "c"} {
+			sum := 0.0
+
+			// "z" stands in for the single bucket of unseen keys
+			for _, key := range []string{"x", "y", "z"} {
+				p, _ := m.smoothedProbability("t", m.t, []string{feature}, []string{key}).Float64()
+
+				sum += p
+			}
+
+			if math.Abs(sum-1) > 1e-12 {
+				t.Errorf("%s probabilities of feature %s sum to %v", smoothing, feature, sum)
+			}
+		}
+	}
+}
@@ -1,23 +1,21 @@
-package main
+package ykm
 
 import (
-	"fmt"
 	"github.com/jonasknobloch/jinn/pkg/tree"
+	"io"
 	"strings"
 )
 
 const UnknownToken = "$X$"
 
-var tokenOccurrences map[string]int
+func CountTokenOccurrences(name, format string, limit int) (map[string]int, error) {
+	it, err := NewIterator(name, format)
 
-func initTokenOccurrences() {
-	tokenOccurrences = countTokenOccurrences()
-}
-
-func countTokenOccurrences() map[string]int {
-	fmt.Println("Counting token occurrences...")
+	if err != nil {
+		return nil, err
+	}
 
-	initCorpus()
+	defer it.Close()
 
 	occurrences := make(map[string]int)
 
@@ -29,22 +27,26 @@ func countTokenOccurrences() map[string]int {
 		}
 	}
 
-	counted, limit := 0, Config.TrainingSampleLimit
+	counted := 0
 
-	for corpus.Next() && (limit == -1 || counted < limit) {
-		sample := corpus.Sample()
+	for it.Next() && (limit == -1 || counted < limit) {
+		sample := it.Sample()
 
 		count(sample.Sentence)
 
 		counted++
 	}
 
-	return occurrences
+	if err := it.Error(); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return occurrences, nil
 }
 
-func replaceSparseLabels(leaves []*tree.Tree, occurrences map[string]int) {
+func replaceSparseLabels(leaves []*tree.Tree, occurrences map[string]int, threshold int) {
 	for _, leaf := range leaves {
-		if occurrences[leaf.Label] > Config.SparseTokenThreshold {
+		if occurrences[leaf.Label] > threshold {
 			continue
 		}
 
@@ -52,9 +54,9 @@ func replaceSparseLabels(leaves []*tree.Tree, occurrences map[string]int) {
 	}
 }
 
-func replaceSparseTokens(tokens []string, occurrences map[string]int) {
+func replaceSparseTokens(tokens []string, occurrences map[string]int, threshold int) {
 	for i, token := range tokens {
-		if occurrences[token] > Config.SparseTokenThreshold {
+		if occurrences[token] > threshold {
 			continue
 		}
 
@@ -0,0 +1,433 @@
+package ykm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+type Trainer struct {
+	opts  Options
+	out   io.Writer
+	model *Model
+}
+
+func NewTrainer(opts Options) *Trainer {
+	return &Trainer{
+		opts: opts,
+		out:  io.Discard,
+	}
+}
+
+func (tr *Trainer) SetOutput(w io.Writer) {
+	tr.out = w
+}
+
+func (tr *Trainer) Model() *Model {
+	return tr.model
+}
+
+func (tr *Trainer) printf(format string, a ...interface{}) {
+	fmt.Fprintf(tr.out, format, a...)
+}
+
+func (tr *Trainer) initModel() (*Checkpoint, int, error) {
+	opts := tr.opts
+
+	if opts.ResumeCheckpoint {
+		cp, err := ReadCheckpoint(opts.CheckpointPath)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		m, err := cp.Restore(opts)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		tr.model = m
+
+		return cp, cp.Iteration - 1, nil
+	}
+
+	if opts.InitModelPath != "" {
+		m, err := LoadModel(opts.InitModelPath, opts)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for _, w := range m.Warnings() {
+			tr.printf("Warning: %s\n", w)
+		}
+
+		tr.model = m
+
+		return nil, opts.InitModelIteration, nil
+	}
+
+	tr.model = NewModel(opts)
+
+	return nil, 0, nil
+}
+
+func (tr *Trainer) Train() (*Model, error) {
+	opts := tr.opts
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	if opts.TrainingDataPath == StdinPath {
+		return nil, errors.New("training corpus must be rewindable and cannot be read from stdin")
+	}
+
+	hash, err := hashCorpus(opts.TrainingDataPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resume, o, err := tr.initModel()
+
+	if err != nil {
+		return nil, err
+	}
+
+	model := tr.model
+
+	if opts.ReplaceSparseTokens || opts.EnablePhrasalTranslations {
+		tr.printf("Counting corpus statistics...\n")
+	}
+
+	if err := model.Prepare(opts.TrainingDataPath); err != nil {
+		return nil, err
+	}
+
+	if opts.EnableOnlineEM {
+		return tr.trainOnline(hash, o)
+	}
+
+	newCount := newCounter(opts)
+
+	nC := newCount()
+	nR := newCount()
+	nT := newCount()
+
+	nL := newCount()
+	nF := newCount()
+
+	if resume != nil {
+		if resume.CorpusHash != hash {
+			return nil, errors.New("checkpoint was written for a different training corpus")
+		}
+
+		if resume.Counts[0].logSpace != opts.LogSpaceArithmetic {
+			return nil, errors.New("checkpoint was written with a different arithmetic backend")
+		}
+
+		nC, nR, nT, nL, nF = resume.Counts[0], resume.Counts[1], resume.Counts[2], resume.Counts[3], resume.Counts[4]
+
+		for _, c := range resume.Counts {
+			c.sorted = opts.EnableReproducibleTraining
+		}
+	}
+
+	checkpoint := func(iteration, position, eval int, skipped []string, lh *big.Float, tokens, scored int) error {
+		cp := &Checkpoint{
+			Iteration:  iteration,
+			Position:   position,
+			Evaluated:  eval,
+			Skipped:    skipped,
+			Likelihood: lh,
+			Tokens:     tokens,
+			Scored:     scored,
+			CorpusHash: hash,
+			Model:      newModelFile(model),
+			Counts:     [5]*Count{nC, nR, nT, nL, nF},
+		}
+
+		if err := WriteCheckpoint(opts.CheckpointPath, cp); err != nil {
+			return fmt.Errorf("error writing checkpoint: %w", err)
+		}
+
+		tr.printf("Wrote checkpoint (iteration: %d position: %d)\n", iteration, position)
+
+		return nil
+	}
+
+	corpus, err := NewIterator(opts.TrainingDataPath, opts.CorpusFormat)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer corpus.Close()
+
+	watch := NewStopWatch()
+
+	var best *Model
+	var bestIteration int
+	var bestLikelihood Likelihood
+
+	posterior := false
+
+	for i := 1 + o; i < opts.TrainingIterationLimit+o+1; i++ {
+		watch.Start()
+
+		tr.printf("\nStarting training iteration #%d\n\n", i)
+
+		if err := corpus.Reset(); err != nil {
+			return nil, err
+		}
+
+		eval := 0
+		position := 0
+		skipped := make([]string, 0)
+
+		lh := big.NewFloat(1)
+		tokens := 0
+		scored := 0
+
+		if resume != nil {
+			eval, position, skipped = resume.Evaluated, resume.Position, resume.Skipped
+			lh, tokens, scored = resume.Likelihood, resume.Tokens, resume.Scored
+
+			for j := 0; j < position && corpus.Next(); j++ {
+			}
+
+			tr.printf("Resuming iteration #%d at position %d\n\n", i, position)
+
+			resume = nil
+		} else {
+			nC.Reset()
+			nR.Reset()
+			nT.Reset()
+
+			nL.Reset()
+			nF.Reset()
+		}
+
+		watch.Lap("init")
+
+		acc := newAccumulator([5]*Count{nC, nR, nT, nL, nF}, opts.ConcurrentSampleEvaluations, batchResult{
+			likelihood: lh,
+			tokens:     tokens,
+			scored:     scored,
+			skipped:    skipped,
+		})
+
+		acc.evaluated = func(r sampleResult) {
+			if r.skip != nil {
+				tr.printf("Skipped sample %s (%s)\n", r.id, r.skip)
+
+				return
+			}
+
+			if r.fail == nil {
+				tr.printf("Evaluated sample %s (eval: %d skip: %d) [%s] [%e]\n", r.id, r.eval, len(acc.skipped), r.watch.Result(), r.p)
+			}
+		}
+
+		var draw func(*Graph) error
+
+		model.training = true
+
+		for corpus.Next() && (opts.TrainingSampleLimit == -1 || eval < opts.TrainingSampleLimit) {
+			position++
+
+			if !corpus.Sample().Positive() || corpus.Sample().Weight == 0 {
+				continue
+			}
+
+			sample := corpus.Sample()
+
+			mt, e, err := model.InitSample(sample)
+
+			if err != nil {
+				acc.skip(sample.ID, err)
+
+				continue
+			}
+
+			if opts.ExportGraphs {
+				draw = func(g *Graph) error {
+					if _, err := g.Draw(strconv.Itoa(i), sample.ID); err != nil {
+						return fmt.Errorf("error drawing graph %d-%s: %w", i, sample.ID, err)
+					}
+
+					return nil
+				}
+			}
+
+			if err := acc.submit(model, sample, mt, e, eval, draw); err != nil {
+				acc.close()
+
+				return nil, err
+			}
+
+			eval++
+
+			if opts.CheckpointInterval > 0 && eval%opts.CheckpointInterval == 0 {
+				acc.wait()
+
+				if err := checkpoint(i, position, eval, acc.skipped, acc.likelihood, acc.tokens, acc.scored); err != nil {
+					acc.close()
+
+					return nil, err
+				}
+			}
+		}
+
+		acc.close()
+
+		model.training = false
+
+		if acc.failed != nil {
+			return nil, acc.failed
+		}
+
+		if err := corpus.Error(); err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		lh, tokens, scored, skipped = acc.likelihood, acc.tokens, acc.scored, acc.skipped
+
+		watch.Lap("samples")
+
+		tr.printf("\nAdjusting model weights...\n")
+
+		if opts.EnableFertilityDecomposition {
+			DecomposeTranslationCount(nT)
+		}
+
+		bound, divergence := posterior, model.Divergence()
+
+		if err := model.UpdateWeights(nC, nR, nT, nL, nF); err != nil {
+			return nil, fmt.Errorf("error updating model weights: %w", err)
+		}
+
+		posterior = opts.TrainingMethod == MethodVB
+
+		watch.Lap("weights")
+
+		if opts.ExportModel {
+			model.info = ModelInfo{
+				Iteration:  i,
+				Config:     opts,
+				CorpusPath: opts.TrainingDataPath,
+				CorpusHash: hash,
+				Vocabulary: model.vocabulary,
+				Lengths:    model.lengths,
+			}
+
+			if err := Export(model, strconv.Itoa(i)); err != nil {
+				return nil, fmt.Errorf("error exporting model: %w", err)
+			}
+
+			watch.Lap("export")
+		}
+
+		if opts.PrintCorpusLikelihood {
+			tr.printf("\nCorpus likelihood: %e", lh)
+		}
+
+		// https://github.com/golang/go/issues/11068
+
+		lhExp := math.Log10(2) * float64(lh.MantExp(nil))
+
+		tr.printf("\nLikelihood exponent: %d\n", int(lhExp))
+
+		training := Likelihood{
+			Total:     LogOf(lh),
+			Tokens:    tokens,
+			Evaluated: scored,
+			Skipped:   len(skipped),
+		}
+
+		if bound {
+			lower := training
+
+			lower.Total -= divergence
+
+			tr.printf("Training lower bound: %s (divergence: %f)\n", lower, divergence)
+		} else {
+			tr.printf("Training log-likelihood: %s\n", training)
+		}
+
+		stop := false
+
+		if opts.HeldOutDataPath != "" {
+			heldOut, err := HeldOutLikelihood(opts.HeldOutDataPath, model)
+
+			if err != nil {
+				return nil, fmt.Errorf("error computing held-out likelihood: %w", err)
+			}
+
+			tr.printf("Held-out log-likelihood: %s\n", heldOut)
+
+			if best == nil || heldOut.PerToken() > bestLikelihood.PerToken() {
+				improvement := heldOut.PerToken() - bestLikelihood.PerToken()
+
+				best, bestIteration, bestLikelihood = model.Copy(), i, heldOut
+
+				stop = opts.EnableEarlyStopping && improvement < opts.EarlyStoppingTolerance
+			} else {
+				stop = opts.EnableEarlyStopping
+			}
+		}
+
+		tr.printf("\n")
+
+		watch.Lap("likelihood")
+
+		// the last iteration has nothing left to resume
+		if opts.CheckpointInterval > 0 && !stop && i < opts.TrainingIterationLimit+o {
+			nC.Reset()
+			nR.Reset()
+			nT.Reset()
+
+			nL.Reset()
+			nF.Reset()
+
+			if err := checkpoint(i+1, 0, 0, make([]string, 0), big.NewFloat(1), 0, 0); err != nil {
+				return nil, err
+			}
+
+			watch.Lap("checkpoint")
+		}
+
+		watch.Stop()
+
+		tr.printf("%s", watch)
+
+		watch.Reset()
+
+		if stop {
+			tr.printf("\nStopping early after iteration #%d (best: #%d)\n", i, bestIteration)
+
+			break
+		}
+	}
+
+	if best == nil {
+		return model, nil
+	}
+
+	tr.printf("\nBest held-out log-likelihood after iteration #%d: %s\n", bestIteration, bestLikelihood)
+
+	tr.model = best
+
+	if opts.ExportModel {
+		if err := Export(best, "final"); err != nil {
+			return nil, fmt.Errorf("error exporting model: %w", err)
+		}
+	}
+
+	return best, nil
+}
@@ -0,0 +1,59 @@
+package ykm
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestConcurrentTrainers(t *testing.T) {
+	configs := []func(*Options){
+		func(o *Options) {},
+		func(o *Options) { o.LogSpaceArithmetic = true },
+		func(o *Options) { o.EnableInteriorInsertions = true },
+		func(o *Options) { o.EnablePhrasalTranslations, o.PhraseLengthLimit = true, 2 },
+	}
+
+	fixtures := make([]*fixture, len(configs))
+	want := make([]string, len(configs))
+
+	for i, configure := range configs {
+		fixtures[i] = newFixture(t, func(o *Options) {
+			configure(o)
+
+			o.EnableReproducibleTraining = true
+		})
+
+		want[i], _ = Checksum(fixtures[i].train())
+	}
+
+	// trainers with different options share no state
+	got := make([]string, len(configs))
+
+	var wg sync.WaitGroup
+
+	for i := range fixtures {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			m, err := NewTrainer(fixtures[i].opts).Train()
+
+			if err != nil {
+				t.Error(err)
+
+				return
+			}
+
+			got[i], _ = Checksum(m)
+		}(i)
+	}
+
+	wg.Wait()
+
+	for i := range configs {
+		if got[i] != want[i] {
+			t.Errorf("config %d: concurrent checksum %s, sequential %s", i, got[i], want[i])
+		}
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package ykm
 
 import (
 	"errors"
@@ -0,0 +1,70 @@
+package ykm
+
+import (
+	"gonum.org/v1/gonum/mathext"
+	"math"
+	"math/big"
+)
+
+const MethodEM = "em"
+const MethodVB = "vb"
+
+func (m *Model) concentration(table string) float64 {
+	switch table {
+	case "n":
+		return m.opts.InsertionConcentration
+	case "r":
+		return m.opts.ReorderingConcentration
+	case "t":
+		return m.opts.TranslationConcentration
+	case "f":
+		return m.opts.FertilityConcentration
+	default:
+		panic("unexpected table")
+	}
+}
+
+// updateVariational sets the weights of every feature to the exponentiated
+// expected log parameters under the Dirichlet posterior and returns the
+// divergence of that posterior from its prior.
+func (m *Model) updateVariational(name string, p map[string]map[string]*big.Float, c *Count) float64 {
+	alpha := m.concentration(name)
+	divergence := 0.0
+
+	for _, feature := range c.Features() {
+		keys := c.FeatureKeys(feature)
+		beta := make([]float64, len(keys))
+		sum := 0.0
+
+		for i, key := range keys {
+			count, _ := c.Get(feature, key).Float64()
+
+			beta[i] = count + alpha
+			sum += beta[i]
+		}
+
+		if _, ok := p[feature]; !ok {
+			p[feature] = make(map[string]*big.Float, len(keys))
+		}
+
+		psi := mathext.Digamma(sum)
+
+		kl := lgamma(sum) - lgamma(alpha*float64(len(keys))) + float64(len(keys))*lgamma(alpha)
+
+		for i, key := range keys {
+			p[feature][key] = ExpOf(mathext.Digamma(beta[i]) - psi)
+
+			kl += -lgamma(beta[i]) + (beta[i]-alpha)*(mathext.Digamma(beta[i])-psi)
+		}
+
+		divergence += kl
+	}
+
+	return divergence
+}
+
+func lgamma(x float64) float64 {
+	v, _ := math.Lgamma(x)
+
+	return v
+}
@@ -0,0 +1,79 @@
+package ykm
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+const eulerGamma = 0.5772156649015329
+
+func variationalWeights(t *testing.T, alpha float64, counts map[string]float64) (map[string]map[string]*big.Float, float64) {
+	t.Helper()
+
+	f := newFixture(t, func(o *Options) {
+		o.TranslationConcentration = alpha
+	})
+
+	m := NewModel(f.opts)
+
+	p := make(map[string]map[string]*big.Float)
+
+	return p, m.updateVariational("t", p, f.count(map[string]map[string]float64{"a": counts}))
+}
+
+func TestUpdateVariational(t *testing.T) {
+	p, _ := variationalWeights(t, 0.5, map[string]float64{"x": 1, "y": 3})
+
+	// exp(digamma(c+alpha) - digamma(sum(c+alpha))) with the closed forms
+	// digamma(1.5) = 2 - gamma - 2ln2 and digamma(5) = 25/12 - gamma
+	psi15 := 2 - eulerGamma - 2*math.Ln2
+	psi35 := psi15 + 1/1.5 + 1/2.5
+	psi5 := 25.0/12 - eulerGamma
+
+	want := map[string]float64{
+		"x": math.Exp(psi15 - psi5),
+		"y": math.Exp(psi35 - psi5),
+	}
+
+	sum := 0.0
+
+	for key, w := range want {
+		got, _ := p["a"][key].Float64()
+
+		if math.Abs(got-w) > 1e-9 {
+			t.Errorf("weight of %s = %v, want %v", key, got, w)
+		}
+
+		sum += got
+	}
+
+	if sum >= 1 {
+		t.Errorf("variational weights should be subnormalized but sum to %v", sum)
+	}
+}
+
+func TestUpdateVariationalLimit(t *testing.T) {
+	p, _ := variationalWeights(t, 0.1, map[string]float64{"x": 1e6, "y": 3e6})
+
+	for key, want := range map[string]float64{"x": 0.25, "y": 0.75} {
+		got, _ := p["a"][key].Float64()
+
+		if math.Abs(got-want) > 1e-6 {
+			t.Errorf("weight of %s = %v, want the relative frequency %v", key, got, want)
+		}
+	}
+}
+
+func TestVariationalDivergence(t *testing.T) {
+	if _, kl := variationalWeights(t, 0.5, map[string]float64{"x": 0, "y": 0}); math.Abs(kl) > 1e-12 {
+		t.Errorf("divergence of the prior from itself = %v, want 0", kl)
+	}
+
+	_, small := variationalWeights(t, 0.5, map[string]float64{"x": 1, "y": 1})
+	_, large := variationalWeights(t, 0.5, map[string]float64{"x": 10, "y": 30})
+
+	if small <= 0 || large <= small {
+		t.Errorf("divergence should grow with the counts but got %v and %v", small, large)
+	}
+}
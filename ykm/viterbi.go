@@ -0,0 +1,139 @@
+package ykm
+
+import "math/big"
+
+type Derivation struct {
+	ID             string           `json:"id"`
+	Probability    *big.Float       `json:"probability"`
+	LogProbability JSONFloat        `json:"log_probability"`
+	Steps          []DerivationStep `json:"steps"`
+}
+
+type DerivationStep struct {
+	Label        string `json:"label"`
+	Source       string `json:"source"`
+	Target       string `json:"target"`
+	Insertion    string `json:"insertion"`
+	InsertedWord string `json:"inserted_word,omitempty"`
+	Reordering   []int  `json:"reordering,omitempty"`
+	Partition    []int  `json:"partition,omitempty"`
+	Translation  string `json:"translation,omitempty"`
+}
+
+type viterbiEntry struct {
+	score     float64
+	insertion *Node
+	choice    *Node
+}
+
+func (g *Graph) BestDerivation() ([]DerivationStep, float64) {
+	best := make(map[*Node]viterbiEntry)
+
+	var max func(n *Node) float64
+	max = func(n *Node) float64 {
+		if e, ok := best[n]; ok {
+			return e.score
+		}
+
+		e := viterbiEntry{score: logZero}
+
+		for _, i := range g.succ[n] {
+			if !i.valid {
+				continue
+			}
+
+			for _, rt := range g.succ[i] {
+				if !rt.valid {
+					continue
+				}
+
+				if rt.nType == FinalNode {
+					score := g.LogEdge(n, i) + g.LogEdge(i, rt)
+
+					if n.lambda != nil && n.kappa != nil {
+						score += n.logLambda
+					}
+
+					if score > e.score {
+						e = viterbiEntry{score, i, rt}
+					}
+				}
+
+				if rt.nType == SubNode {
+					for _, p := range g.succ[rt] {
+						if !p.valid {
+							continue
+						}
+
+						score := g.LogEdge(n, i) + g.LogEdge(i, rt)
+
+						if n.lambda != nil && n.kappa != nil {
+							score += n.logKappa
+						}
+
+						for _, m := range g.succ[p] {
+							if !m.valid {
+								continue
+							}
+
+							score += max(m)
+						}
+
+						if score > e.score {
+							e = viterbiEntry{score, i, p}
+						}
+					}
+				}
+			}
+		}
+
+		best[n] = e
+
+		return e.score
+	}
+
+	score := max(g.nodes[0])
+
+	steps := make([]DerivationStep, 0)
+
+	var trace func(n *Node)
+	trace = func(n *Node) {
+		e := best[n]
+
+		step := DerivationStep{
+			Label:        n.tree.Label,
+			Source:       n.tree.Sentence(),
+			Target:       n.Substring(),
+			Insertion:    string(e.insertion.n.Position),
+			InsertedWord: e.insertion.n.Word,
+		}
+
+		if e.choice.nType == FinalNode {
+			step.Translation = e.choice.t.Key()
+			steps = append(steps, step)
+
+			return
+		}
+
+		step.Reordering = e.choice.r.Reordering
+		step.Partition = e.choice.p
+
+		steps = append(steps, step)
+
+		for _, m := range g.succ[e.choice] {
+			if !m.valid {
+				continue
+			}
+
+			trace(m)
+		}
+	}
+
+	if best[g.nodes[0]].insertion == nil {
+		return steps, score
+	}
+
+	trace(g.nodes[0])
+
+	return steps, score
+}
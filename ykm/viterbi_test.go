@@ -0,0 +1,43 @@
+package ykm
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestBestDerivation(t *testing.T) {
+	for _, logSpace := range []bool{false, true} {
+		f := newFixture(t, func(o *Options) {
+			o.LogSpaceArithmetic = logSpace
+		})
+
+		m := f.train()
+
+		graphs, samples := f.graphs(m)
+
+		for i, g := range graphs {
+			steps, score := g.BestDerivation()
+
+			// the best derivation is one of the derivations summed over
+			if logp := g.LogProbability(); score > logp+1e-9 || math.IsInf(score, -1) {
+				t.Errorf("sample %s: best derivation %v, log probability %v", samples[i].ID, score, logp)
+			}
+
+			if len(steps) == 0 {
+				t.Fatalf("sample %s: empty derivation", samples[i].ID)
+			}
+
+			if root := steps[0]; root.Target != strings.Join(strings.Fields(samples[i].Sentence), " ") {
+				t.Errorf("sample %s: root step covers %q", samples[i].ID, root.Target)
+			}
+
+			// every step either translates or reorders its children
+			for _, s := range steps {
+				if (s.Translation == "") == (len(s.Reordering) == 0) {
+					t.Errorf("sample %s: step %+v", samples[i].ID, s)
+				}
+			}
+		}
+	}
+}